@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"os"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/rs/cors"
@@ -33,8 +35,36 @@ func main() {
 	ItemRepository := repository.NewItemRepository(db)
 	itemService := service.NewItemService(*ItemRepository)
 
+	StockOpnameRepository := repository.NewStockOpnameRepository(db)
+	StockOpnameService := service.NewStockOpnameService(*StockOpnameRepository, *ItemRepository, *StorageRepository)
+
+	EmployeeRepository := repository.NewEmployeeRepository(db)
+	EmployeeService := service.NewEmployeeService(*EmployeeRepository)
+
+	QuotaRepository := repository.NewQuotaRepository(db)
+	QuotaService := service.NewQuotaService(*QuotaRepository)
+
+	ApprovalRuleRepository := repository.NewApprovalRuleRepository(db)
+	ApprovalRuleService := service.NewApprovalRuleService(*ApprovalRuleRepository, *ItemRepository, *EmployeeRepository)
+
+	ApprovalChainRepository := repository.NewApprovalChainRepository(db)
+	ApprovalChainService := service.NewApprovalChainService(*ApprovalChainRepository)
+
 	TransactionRepository := repository.NewTransactionRepository(db)
-	TransactionService := service.NewTransactionService(*TransactionRepository, *ItemRepository)
+	TransactionService := service.NewTransactionService(*TransactionRepository, *ItemRepository, *StockOpnameRepository, *EmployeeRepository, *QuotaRepository, *ApprovalRuleRepository, *ApprovalChainRepository, *CategoryRepository)
+
+	WriteOffRepository := repository.NewWriteOffRepository(db)
+	WriteOffService := service.NewWriteOffService(*WriteOffRepository)
+
+	IdempotencyRepository := repository.NewIdempotencyRepository(db)
+	IdempotencyService := service.NewIdempotencyService(*IdempotencyRepository)
+	IdempotencyService.StartIdempotencyKeyPurge(time.Hour)
+
+	overdueInterval, err := time.ParseDuration(os.Getenv("OVERDUE_CHECK_INTERVAL"))
+	if err != nil || overdueInterval <= 0 {
+		overdueInterval = 15 * time.Minute
+	}
+	TransactionService.StartOverdueScheduler(overdueInterval)
 
 	r := mux.NewRouter()
 
@@ -51,12 +81,18 @@ func main() {
 	routes.CategoryRoutes(r, categoryService, jwtUtils)
 	routes.StorageRoutes(r, StorageService, jwtUtils)
 	routes.ItemRoutes(r, itemService, jwtUtils)
-	routes.TransactionRoutes(r, TransactionService, jwtUtils)
+	routes.TransactionRoutes(r, TransactionService, IdempotencyService, jwtUtils)
+	routes.StockOpnameRoutes(r, StockOpnameService, jwtUtils)
+	routes.EmployeeRoutes(r, EmployeeService, jwtUtils)
+	routes.QuotaRoutes(r, QuotaService, jwtUtils)
+	routes.ApprovalRuleRoutes(r, ApprovalRuleService, jwtUtils)
+	routes.ApprovalChainRoutes(r, ApprovalChainService, jwtUtils)
+	routes.WriteOffRoutes(r, WriteOffService, jwtUtils)
 
 	c := cors.New(cors.Options{
     	AllowedOrigins:   []string{"http://localhost:3000"},
 		AllowCredentials: true,
-		AllowedHeaders: []string{"Authorization", "Content-Type", "X-Requested-With"},
+		AllowedHeaders: []string{"Authorization", "Content-Type", "X-Requested-With", "X-Tracking-Token", "Idempotency-Key"},
 		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS", "PATCH", "PUT"},
 	})
 
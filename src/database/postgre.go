@@ -25,7 +25,7 @@ func Connect() (*gorm.DB, error) {
 
 	dsn := fmt.Sprintf("postgresql://%s:%s@%s:%s/%s?sslmode=disable&TimeZone=Asia/Jakarta",
 		dbUser, dbPass, dbHost, dbPort, dbName)
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{TranslateError: true})
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect database: %w", err)
 	}
@@ -38,17 +38,209 @@ func Connect() (*gorm.DB, error) {
     UPDATE insertion_transactions SET uuid = uuid_generate_v4() WHERE uuid IS NULL;
 	`)
 
+	backfillReserved := db.Migrator().HasTable(&model.Item{}) && !db.Migrator().HasColumn(&model.Item{}, "Reserved")
+	backfillMovements := !db.Migrator().HasTable(&model.ItemMovement{})
+	backfillEmployees := !db.Migrator().HasTable(&model.Employee{})
+	backfillReturnConditions := db.Migrator().HasTable(&model.LoanReturn{}) && !db.Migrator().HasColumn(&model.LoanReturn{}, "Good")
+
+	// Idempotency keys used to be unique per endpoint only, which let clients
+	// sending the same key collide. They are now unique per client too.
+	if err := db.Exec(`DROP INDEX IF EXISTS idx_idempotency_keys_scope;`).Error; err != nil {
+		log.Fatalf("Could not drop idempotency key index: %v", err)
+	}
+
 	if err := db.AutoMigrate(
 		&model.Admin{},
 		&model.Storage{},
 		&model.Item{},
+		&model.ItemMovement{},
 		&model.Category{},
+		&model.Employee{},
 		&model.LoanTransaction{},
+		&model.LoanReturn{},
+		&model.LoanExtension{},
 		&model.InquiryTransaction{},
+		&model.TransactionLine{},
+		&model.TransactionEvent{},
 		&model.InsertionTransaction{},
+		&model.StockOpname{},
+		&model.StockOpnameCount{},
+		&model.StockOpnameReportLine{},
+		&model.IdempotencyKey{},
+		&model.ConsumptionQuota{},
+		&model.QuotaViolation{},
+		&model.ApprovalRule{},
+		&model.ApprovalChain{},
+		&model.ApprovalChainStep{},
+		&model.AdminRole{},
+		&model.TransactionApproval{},
+		&model.WriteOff{},
+		&model.TransferTransaction{},
 	); err != nil {
 		log.Fatalf("Could not migrate: %v", err)
 	}
 
+	// Transactions approved before stock reservations existed still hold their
+	// items, so reserve them once when the column is first added.
+	if backfillReserved {
+		if err := db.Exec(`
+		UPDATE items SET reserved = LEAST(items.quantity, approved.quantity)
+		FROM (
+			SELECT item_id, SUM(quantity) AS quantity FROM (
+				SELECT item_id, quantity FROM loan_transactions WHERE status = 'approved'
+				UNION ALL
+				SELECT item_id, quantity FROM inquiry_transactions WHERE status = 'approved'
+			) approved_transactions
+			GROUP BY item_id
+		) approved
+		WHERE items.id = approved.item_id;
+		`).Error; err != nil {
+			log.Fatalf("Could not backfill reserved quantities: %v", err)
+		}
+	}
+
+	// Items that existed before the stock ledger start it with their quantity
+	// at the time it was introduced.
+	if backfillMovements {
+		if err := db.Exec(`
+		INSERT INTO item_movements (item_id, delta, balance, reason, transaction_uuid, time)
+		SELECT id, quantity, quantity, 'opening_balance', '', NOW() FROM items;
+		`).Error; err != nil {
+			log.Fatalf("Could not backfill item movements: %v", err)
+		}
+	}
+
+	// Returns recorded before return conditions existed all went back to
+	// stock, so they count as good.
+	if backfillReturnConditions {
+		if err := db.Exec(`UPDATE loan_returns SET good = quantity;`).Error; err != nil {
+			log.Fatalf("Could not backfill return conditions: %v", err)
+		}
+	}
+
+	// Loans returned before partial returns existed were returned in full.
+	if err := db.Exec(`UPDATE loan_transactions SET returned_quantity = quantity WHERE status = 'returned' AND returned_quantity = 0;`).Error; err != nil {
+		log.Fatalf("Could not backfill returned quantities: %v", err)
+	}
+
+	// Transaction lines, write-offs and transfer sources used to let their
+	// item be deleted, leaving a NULL item behind.
+	// AutoMigrate does not change an existing constraint, so replace it.
+	if err := restrictItemDeletion(db, "transaction_lines", "item_id", "fk_transaction_lines_item"); err != nil {
+		log.Fatalf("Could not restrict item deletion: %v", err)
+	}
+	if err := restrictItemDeletion(db, "write_offs", "item_id", "fk_write_offs_item"); err != nil {
+		log.Fatalf("Could not restrict item deletion: %v", err)
+	}
+	if err := restrictItemDeletion(db, "transfer_transactions", "source_item_id", "fk_transfer_transactions_source_item"); err != nil {
+		log.Fatalf("Could not restrict item deletion: %v", err)
+	}
+
+	// Single-item loans and inquiries created before line items existed get
+	// a line mirroring their item and quantity.
+	if err := db.Exec(`
+	INSERT INTO transaction_lines (transaction_type, transaction_id, item_id, quantity, returned_quantity, status, completed_time)
+	SELECT 'loan', lt.id, lt.item_id, lt.quantity, lt.returned_quantity,
+		CASE lt.status WHEN 'overdue' THEN 'completed' WHEN 'incomplete' THEN 'pending' ELSE lt.status END,
+		lt.completed_time
+	FROM loan_transactions lt
+	WHERE lt.item_id IS NOT NULL
+		AND NOT EXISTS (SELECT 1 FROM transaction_lines tl WHERE tl.transaction_type = 'loan' AND tl.transaction_id = lt.id);
+
+	INSERT INTO transaction_lines (transaction_type, transaction_id, item_id, quantity, returned_quantity, status, completed_time)
+	SELECT 'inquiry', it.id, it.item_id, it.quantity, 0,
+		CASE it.status WHEN 'incomplete' THEN 'pending' ELSE it.status END,
+		it.completed_time
+	FROM inquiry_transactions it
+	WHERE it.item_id IS NOT NULL
+		AND NOT EXISTS (SELECT 1 FROM transaction_lines tl WHERE tl.transaction_type = 'inquiry' AND tl.transaction_id = it.id);
+	`).Error; err != nil {
+		log.Fatalf("Could not backfill transaction lines: %v", err)
+	}
+
+	// Seed the employee directory once from the people named on existing
+	// transactions, using their latest department and position, and link the
+	// transactions to them by normalized name.
+	if backfillEmployees {
+		if err := db.Exec(`
+		INSERT INTO employees (name, normalized_name, department, position, created_time)
+		SELECT DISTINCT ON (normalized_name)
+			regexp_replace(btrim(employee_name), '\s+', ' ', 'g'), normalized_name, employee_department, employee_position, NOW()
+		FROM (
+			SELECT employee_name, employee_department, employee_position, "time",
+				lower(regexp_replace(btrim(employee_name), '\s+', ' ', 'g')) AS normalized_name
+			FROM loan_transactions
+			UNION ALL
+			SELECT employee_name, employee_department, employee_position, "time",
+				lower(regexp_replace(btrim(employee_name), '\s+', ' ', 'g'))
+			FROM inquiry_transactions
+			UNION ALL
+			SELECT employee_name, employee_department, employee_position, "time",
+				lower(regexp_replace(btrim(employee_name), '\s+', ' ', 'g'))
+			FROM insertion_transactions
+		) named
+		WHERE normalized_name <> ''
+		ORDER BY normalized_name, "time" DESC;
+
+		UPDATE loan_transactions t SET employee_id = e.id FROM employees e
+		WHERE t.employee_id IS NULL AND e.normalized_name = lower(regexp_replace(btrim(t.employee_name), '\s+', ' ', 'g'));
+
+		UPDATE inquiry_transactions t SET employee_id = e.id FROM employees e
+		WHERE t.employee_id IS NULL AND e.normalized_name = lower(regexp_replace(btrim(t.employee_name), '\s+', ' ', 'g'));
+
+		UPDATE insertion_transactions t SET employee_id = e.id FROM employees e
+		WHERE t.employee_id IS NULL AND e.normalized_name = lower(regexp_replace(btrim(t.employee_name), '\s+', ' ', 'g'));
+		`).Error; err != nil {
+			log.Fatalf("Could not backfill employees: %v", err)
+		}
+	}
+
+	// A storage can only be counted by one stock opname at a time.
+	if err := db.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS idx_stock_opnames_open_storage ON stock_opnames (storage_id) WHERE status = 'open';`).Error; err != nil {
+		log.Fatalf("Could not create open stock opname index: %v", err)
+	}
+
+	// The transaction listing pages through every transaction type at once,
+	// so it reads from a single view. It is rebuilt on startup to pick up
+	// column changes.
+	if err := db.Exec(`
+	DROP VIEW IF EXISTS transaction_feed;
+	CREATE VIEW transaction_feed AS
+		SELECT 'loan' AS transaction_type, id, uuid, employee_id, employee_name, employee_department, status, notes, "time",
+			NULL::BIGINT AS item_id, NULL::BIGINT AS item_request_category_id, deleted_at
+		FROM loan_transactions
+		UNION ALL
+		SELECT 'inquiry', id, uuid, employee_id, employee_name, employee_department, status, notes, "time",
+			NULL::BIGINT, NULL::BIGINT, deleted_at
+		FROM inquiry_transactions
+		UNION ALL
+		SELECT 'insert', id, uuid, employee_id, employee_name, employee_department, status, notes, "time",
+			item_id, item_request_category_id, deleted_at
+		FROM insertion_transactions
+		UNION ALL
+		SELECT 'transfer', id, uuid, NULL::BIGINT, '', '', status, notes, "time",
+			source_item_id, destination_category_id, deleted_at
+		FROM transfer_transactions;
+	`).Error; err != nil {
+		log.Fatalf("Could not create transaction feed view: %v", err)
+	}
+
 	return db, nil
 }
+
+// restrictItemDeletion makes the item foreign key of a table refuse the
+// deletion of referenced items, replacing a constraint that set them to NULL.
+func restrictItemDeletion(db *gorm.DB, table, column, constraint string) error {
+	var action string
+	if err := db.Raw(`SELECT confdeltype FROM pg_constraint WHERE conname = ?`, constraint).Scan(&action).Error; err != nil {
+		return err
+	}
+	if action == "" || action == "r" {
+		return nil
+	}
+
+	return db.Exec(fmt.Sprintf(`
+	ALTER TABLE %[1]s DROP CONSTRAINT %[3]s;
+	ALTER TABLE %[1]s ADD CONSTRAINT %[3]s FOREIGN KEY (%[2]s) REFERENCES items (id) ON UPDATE CASCADE ON DELETE RESTRICT;
+	`, table, column, constraint)).Error
+}
@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+	"gtihub.com/raditsoic/telkom-storage-ms/src/model"
+)
+
+type ApprovalChainRepository struct {
+	db *gorm.DB
+}
+
+func NewApprovalChainRepository(db *gorm.DB) *ApprovalChainRepository {
+	return &ApprovalChainRepository{db: db}
+}
+
+// WithTx returns a copy of the repository bound to the given database transaction.
+func (repository *ApprovalChainRepository) WithTx(tx *gorm.DB) *ApprovalChainRepository {
+	return &ApprovalChainRepository{db: tx}
+}
+
+func orderedChainSteps(db *gorm.DB) *gorm.DB {
+	return db.Order("position ASC")
+}
+
+func (repository *ApprovalChainRepository) CreateApprovalChain(chain *model.ApprovalChain) error {
+	if err := repository.db.Create(chain).Error; err != nil {
+		return fmt.Errorf("failed to create approval chain: %w", err)
+	}
+
+	return nil
+}
+
+func (repository *ApprovalChainRepository) GetApprovalChainByID(id uint) (*model.ApprovalChain, error) {
+	var chain model.ApprovalChain
+	if err := repository.db.Preload("Steps", orderedChainSteps).First(&chain, id).Error; err != nil {
+		return nil, fmt.Errorf("failed to get approval chain: %w", err)
+	}
+
+	return &chain, nil
+}
+
+// GetApprovalChains lists the chains in the order they are tried.
+func (repository *ApprovalChainRepository) GetApprovalChains(activeOnly bool) ([]model.ApprovalChain, error) {
+	query := repository.db.Model(&model.ApprovalChain{}).Preload("Steps", orderedChainSteps)
+	if activeOnly {
+		query = query.Where("active = ?", true)
+	}
+
+	var chains []model.ApprovalChain
+	if err := query.Order("priority, id").Find(&chains).Error; err != nil {
+		return nil, fmt.Errorf("failed to get approval chains: %w", err)
+	}
+
+	return chains, nil
+}
+
+// UpdateApprovalChain saves a chain and replaces its steps.
+func (repository *ApprovalChainRepository) UpdateApprovalChain(chain *model.ApprovalChain) error {
+	return repository.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("chain_id = ?", chain.ID).Delete(&model.ApprovalChainStep{}).Error; err != nil {
+			return fmt.Errorf("failed to update approval chain: %w", err)
+		}
+		for i := range chain.Steps {
+			chain.Steps[i].ID = 0
+			chain.Steps[i].ChainID = chain.ID
+		}
+		if err := tx.Save(chain).Error; err != nil {
+			return fmt.Errorf("failed to update approval chain: %w", err)
+		}
+
+		return nil
+	})
+}
+
+func (repository *ApprovalChainRepository) DeleteApprovalChain(id uint) error {
+	if err := repository.db.Delete(&model.ApprovalChain{}, id).Error; err != nil {
+		return fmt.Errorf("failed to delete approval chain: %w", err)
+	}
+
+	return nil
+}
+
+func (repository *ApprovalChainRepository) GetAdminRoles(adminID uint) ([]model.AdminRole, error) {
+	var roles []model.AdminRole
+	if err := repository.db.Where("admin_id = ?", adminID).Order("role, department").Find(&roles).Error; err != nil {
+		return nil, fmt.Errorf("failed to get admin roles: %w", err)
+	}
+
+	return roles, nil
+}
+
+// SetAdminRoles replaces every role of an admin.
+func (repository *ApprovalChainRepository) SetAdminRoles(adminID uint, roles []model.AdminRole) error {
+	return repository.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("admin_id = ?", adminID).Delete(&model.AdminRole{}).Error; err != nil {
+			return fmt.Errorf("failed to set admin roles: %w", err)
+		}
+		if len(roles) == 0 {
+			return nil
+		}
+		if err := tx.Create(&roles).Error; err != nil {
+			return fmt.Errorf("failed to set admin roles: %w", err)
+		}
+
+		return nil
+	})
+}
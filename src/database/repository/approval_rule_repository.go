@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+	"gtihub.com/raditsoic/telkom-storage-ms/src/model"
+)
+
+type ApprovalRuleRepository struct {
+	db *gorm.DB
+}
+
+func NewApprovalRuleRepository(db *gorm.DB) *ApprovalRuleRepository {
+	return &ApprovalRuleRepository{db: db}
+}
+
+// WithTx returns a copy of the repository bound to the given database transaction.
+func (repository *ApprovalRuleRepository) WithTx(tx *gorm.DB) *ApprovalRuleRepository {
+	return &ApprovalRuleRepository{db: tx}
+}
+
+func (repository *ApprovalRuleRepository) CreateApprovalRule(rule *model.ApprovalRule) error {
+	if err := repository.db.Create(rule).Error; err != nil {
+		return fmt.Errorf("failed to create approval rule: %w", err)
+	}
+
+	return nil
+}
+
+func (repository *ApprovalRuleRepository) GetApprovalRuleByID(id uint) (*model.ApprovalRule, error) {
+	var rule model.ApprovalRule
+	if err := repository.db.First(&rule, id).Error; err != nil {
+		return nil, fmt.Errorf("failed to get approval rule: %w", err)
+	}
+
+	return &rule, nil
+}
+
+// GetApprovalRules lists the rules in the order they are tried.
+func (repository *ApprovalRuleRepository) GetApprovalRules(activeOnly bool) ([]model.ApprovalRule, error) {
+	query := repository.db.Model(&model.ApprovalRule{})
+	if activeOnly {
+		query = query.Where("active = ?", true)
+	}
+
+	var rules []model.ApprovalRule
+	if err := query.Order("priority, id").Find(&rules).Error; err != nil {
+		return nil, fmt.Errorf("failed to get approval rules: %w", err)
+	}
+
+	return rules, nil
+}
+
+func (repository *ApprovalRuleRepository) UpdateApprovalRule(rule *model.ApprovalRule) error {
+	if err := repository.db.Save(rule).Error; err != nil {
+		return fmt.Errorf("failed to update approval rule: %w", err)
+	}
+
+	return nil
+}
+
+func (repository *ApprovalRuleRepository) DeleteApprovalRule(id uint) error {
+	if err := repository.db.Delete(&model.ApprovalRule{}, id).Error; err != nil {
+		return fmt.Errorf("failed to delete approval rule: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,140 @@
+package repository
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+	"gtihub.com/raditsoic/telkom-storage-ms/src/model"
+)
+
+type EmployeeRepository struct {
+	db *gorm.DB
+}
+
+func NewEmployeeRepository(db *gorm.DB) *EmployeeRepository {
+	return &EmployeeRepository{db: db}
+}
+
+func (repository *EmployeeRepository) CreateEmployee(employee *model.Employee) error {
+	if err := repository.db.Create(employee).Error; err != nil {
+		return fmt.Errorf("failed to create employee: %w", err)
+	}
+
+	return nil
+}
+
+func (repository *EmployeeRepository) GetEmployeeByID(id uint) (*model.Employee, error) {
+	var employee model.Employee
+	if err := repository.db.First(&employee, id).Error; err != nil {
+		return nil, fmt.Errorf("failed to get employee: %w", err)
+	}
+
+	return &employee, nil
+}
+
+// GetEmployeesByNormalizedName lists the employees whose normalized name is
+// exactly the given one.
+func (repository *EmployeeRepository) GetEmployeesByNormalizedName(name string) ([]model.Employee, error) {
+	var employees []model.Employee
+	if err := repository.db.Where("normalized_name = ?", name).Find(&employees).Error; err != nil {
+		return nil, fmt.Errorf("failed to get employees: %w", err)
+	}
+
+	return employees, nil
+}
+
+// GetEmployees lists the directory ordered by name. Search matches the name,
+// ID number or department.
+func (repository *EmployeeRepository) GetEmployees(search string, limit, offset int) ([]model.Employee, int64, error) {
+	query := repository.db.Model(&model.Employee{})
+	if search != "" {
+		pattern := "%" + search + "%"
+		query = query.Where("name ILIKE ? OR id_number ILIKE ? OR department ILIKE ?", pattern, pattern, pattern)
+	}
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count employees: %w", err)
+	}
+
+	var employees []model.Employee
+	if err := query.Order("normalized_name, id").Limit(limit).Offset(offset).Find(&employees).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to get employees: %w", err)
+	}
+
+	return employees, total, nil
+}
+
+// SearchEmployees returns the employees whose name contains the given
+// normalized prefix, listing names that start with it first.
+func (repository *EmployeeRepository) SearchEmployees(name string, limit int) ([]model.Employee, error) {
+	var employees []model.Employee
+	if err := repository.db.
+		Where("normalized_name LIKE ?", "%"+name+"%").
+		Order(gorm.Expr("normalized_name LIKE ? DESC, normalized_name, id", name+"%")).
+		Limit(limit).
+		Find(&employees).Error; err != nil {
+		return nil, fmt.Errorf("failed to search employees: %w", err)
+	}
+
+	return employees, nil
+}
+
+func (repository *EmployeeRepository) UpdateEmployee(employee *model.Employee) error {
+	if err := repository.db.Save(employee).Error; err != nil {
+		return fmt.Errorf("failed to update employee: %w", err)
+	}
+
+	return nil
+}
+
+func (repository *EmployeeRepository) DeleteEmployee(id uint) error {
+	if err := repository.db.Delete(&model.Employee{}, id).Error; err != nil {
+		return fmt.Errorf("failed to delete employee: %w", err)
+	}
+
+	return nil
+}
+
+// GetOpenLoans lists the loans of an employee that are still in progress or
+// have items out.
+func (repository *EmployeeRepository) GetOpenLoans(employeeID uint, statuses []string) ([]model.LoanTransaction, error) {
+	var loans []model.LoanTransaction
+	if err := repository.db.Preload("Lines.Item").
+		Where("employee_id = ? AND status IN ?", employeeID, statuses).
+		Order("time DESC").
+		Find(&loans).Error; err != nil {
+		return nil, fmt.Errorf("failed to get open loans: %w", err)
+	}
+
+	return loans, nil
+}
+
+func (repository *EmployeeRepository) GetInquiries(employeeID uint) ([]model.InquiryTransaction, error) {
+	var inquiries []model.InquiryTransaction
+	if err := repository.db.Preload("Lines.Item").
+		Where("employee_id = ?", employeeID).
+		Order("time DESC").
+		Find(&inquiries).Error; err != nil {
+		return nil, fmt.Errorf("failed to get inquiries: %w", err)
+	}
+
+	return inquiries, nil
+}
+
+// GetConsumption sums the completed inquiry lines of an employee per item.
+func (repository *EmployeeRepository) GetConsumption(employeeID uint) ([]model.EmployeeConsumption, error) {
+	var consumption []model.EmployeeConsumption
+	if err := repository.db.Model(&model.TransactionLine{}).
+		Select("transaction_lines.item_id, items.name AS item_name, SUM(transaction_lines.quantity) AS quantity").
+		Joins("JOIN inquiry_transactions ON inquiry_transactions.id = transaction_lines.transaction_id AND transaction_lines.transaction_type = 'inquiry' AND inquiry_transactions.deleted_at IS NULL").
+		Joins("LEFT JOIN items ON items.id = transaction_lines.item_id").
+		Where("inquiry_transactions.employee_id = ? AND transaction_lines.status = ?", employeeID, "completed").
+		Group("transaction_lines.item_id, items.name").
+		Order("quantity DESC").
+		Scan(&consumption).Error; err != nil {
+		return nil, fmt.Errorf("failed to get consumption: %w", err)
+	}
+
+	return consumption, nil
+}
@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gtihub.com/raditsoic/telkom-storage-ms/src/model"
+)
+
+type IdempotencyRepository struct {
+	db *gorm.DB
+}
+
+func NewIdempotencyRepository(db *gorm.DB) *IdempotencyRepository {
+	return &IdempotencyRepository{db: db}
+}
+
+// CreateIdempotencyKey claims a key of a client for an endpoint. It fails with
+// gorm.ErrDuplicatedKey when the key was already claimed.
+func (repository *IdempotencyRepository) CreateIdempotencyKey(record *model.IdempotencyKey) error {
+	if err := repository.db.Create(record).Error; err != nil {
+		return fmt.Errorf("failed to create idempotency key: %w", err)
+	}
+
+	return nil
+}
+
+func (repository *IdempotencyRepository) GetIdempotencyKey(endpoint, client, key string) (*model.IdempotencyKey, error) {
+	var record model.IdempotencyKey
+	if err := repository.db.Where("endpoint = ? AND client = ? AND key = ?", endpoint, client, key).First(&record).Error; err != nil {
+		return nil, fmt.Errorf("failed to get idempotency key: %w", err)
+	}
+
+	return &record, nil
+}
+
+func (repository *IdempotencyRepository) UpdateIdempotencyKey(record *model.IdempotencyKey) error {
+	if err := repository.db.Save(record).Error; err != nil {
+		return fmt.Errorf("failed to update idempotency key: %w", err)
+	}
+
+	return nil
+}
+
+func (repository *IdempotencyRepository) DeleteIdempotencyKey(id uint) error {
+	if err := repository.db.Delete(&model.IdempotencyKey{}, id).Error; err != nil {
+		return fmt.Errorf("failed to delete idempotency key: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteInProgressIdempotencyKey releases a key whose request never
+// finished. Keys holding a stored response are left alone.
+func (repository *IdempotencyRepository) DeleteInProgressIdempotencyKey(endpoint, client, key string) error {
+	if err := repository.db.Where("endpoint = ? AND client = ? AND key = ? AND status_code = 0", endpoint, client, key).Delete(&model.IdempotencyKey{}).Error; err != nil {
+		return fmt.Errorf("failed to release idempotency key: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteIdempotencyKeysBefore removes the keys claimed before cutoff and
+// returns how many there were.
+func (repository *IdempotencyRepository) DeleteIdempotencyKeysBefore(cutoff time.Time) (int64, error) {
+	result := repository.db.Where("created_time < ?", cutoff).Delete(&model.IdempotencyKey{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to delete expired idempotency keys: %w", result.Error)
+	}
+
+	return result.RowsAffected, nil
+}
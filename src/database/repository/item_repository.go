@@ -1,22 +1,68 @@
 package repository
 
 import (
+	"errors"
 	"fmt"
+	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"gtihub.com/raditsoic/telkom-storage-ms/src/model"
+	"gtihub.com/raditsoic/telkom-storage-ms/src/utils"
 )
 
 type ItemRepository struct {
 	db *gorm.DB
+
+	// transactionID and actorID are stamped on the stock movements recorded
+	// through this repository.
+	transactionID string
+	actorID       *uint
 }
 
 func NewItemRepository(db *gorm.DB) *ItemRepository {
 	return &ItemRepository{db: db}
 }
 
-func (repo *ItemRepository) CreateItem(item *model.Item) (*model.Item, error) {
-	if err := repo.db.Create(item).Error; err != nil {
+// WithTx returns a copy of the repository bound to the given database transaction.
+func (repo *ItemRepository) WithTx(tx *gorm.DB) *ItemRepository {
+	return &ItemRepository{db: tx, transactionID: repo.transactionID, actorID: repo.actorID}
+}
+
+// WithSource returns a copy of the repository that attributes the stock
+// movements it records to the given transaction and admin.
+func (repo *ItemRepository) WithSource(transactionID string, actorID *uint) *ItemRepository {
+	return &ItemRepository{db: repo.db, transactionID: transactionID, actorID: actorID}
+}
+
+func (repo *ItemRepository) recordMovement(itemID uint, delta, balance int, reason string) error {
+	movement := model.ItemMovement{
+		ItemID:          itemID,
+		Delta:           delta,
+		Balance:         balance,
+		Reason:          reason,
+		TransactionUUID: repo.transactionID,
+		ActorID:         repo.actorID,
+		Time:            time.Now(),
+	}
+	if err := repo.db.Create(&movement).Error; err != nil {
+		return fmt.Errorf("failed to record item movement: %w", err)
+	}
+
+	return nil
+}
+
+// CreateItem creates an item and records its starting quantity in the stock
+// ledger under the given reason.
+func (repo *ItemRepository) CreateItem(item *model.Item, reason string) (*model.Item, error) {
+	err := repo.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(item).Error; err != nil {
+			return err
+		}
+
+		return repo.WithTx(tx).recordMovement(item.ID, item.Quantity, item.Quantity, reason)
+	})
+	if err != nil {
 		return nil, fmt.Errorf("failed to create item: %w", err)
 	}
 	return item, nil
@@ -30,6 +76,105 @@ func (repo *ItemRepository) GetItemByID(id string) (*model.Item, error) {
 	return &item, nil
 }
 
+// GetItemByIDForUpdate loads an item and locks its row until the surrounding
+// transaction ends. It must be called on a repository returned by WithTx.
+func (repo *ItemRepository) GetItemByIDForUpdate(id uint) (*model.Item, error) {
+	var item model.Item
+	if err := repo.db.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(&item).Error; err != nil {
+		return nil, fmt.Errorf("failed to lock item: %w", err)
+	}
+
+	return &item, nil
+}
+
+// AdjustItemQuantity changes the quantity of an item by delta with a relative
+// update, refusing to let the stock drop below what is already reserved. The
+// change is recorded in the stock ledger under the given reason.
+func (repo *ItemRepository) AdjustItemQuantity(id uint, delta int, reason string) error {
+	var item model.Item
+	result := repo.db.Model(&item).
+		Clauses(clause.Returning{Columns: []clause.Column{{Name: "quantity"}}}).
+		Where("id = ? AND quantity + ? >= reserved", id, delta).
+		UpdateColumn("quantity", gorm.Expr("quantity + ?", delta))
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrCheckConstraintViolated) {
+			return utils.ErrInsufficientQuantity
+		}
+		return fmt.Errorf("failed to adjust item quantity: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return utils.ErrInsufficientQuantity
+	}
+
+	return repo.recordMovement(id, delta, item.Quantity, reason)
+}
+
+// ReserveItemQuantity sets aside quantity units of an item for an approved
+// transaction, failing when fewer units are available.
+func (repo *ItemRepository) ReserveItemQuantity(id uint, quantity int) error {
+	result := repo.db.Model(&model.Item{}).
+		Where("id = ? AND quantity - reserved >= ?", id, quantity).
+		UpdateColumn("reserved", gorm.Expr("reserved + ?", quantity))
+	return reservationResult(result, "reserve")
+}
+
+// ReleaseItemQuantity gives back units previously set aside by
+// ReserveItemQuantity without touching the quantity on hand, failing when
+// fewer units are reserved.
+func (repo *ItemRepository) ReleaseItemQuantity(id uint, quantity int) error {
+	result := repo.db.Model(&model.Item{}).
+		Where("id = ? AND reserved >= ?", id, quantity).
+		UpdateColumn("reserved", gorm.Expr("reserved - ?", quantity))
+	return reservationResult(result, "release")
+}
+
+// ConsumeReservedItemQuantity takes reserved units out of stock, lowering both
+// the quantity on hand and the reserved quantity. The change is recorded in
+// the stock ledger under the given reason.
+func (repo *ItemRepository) ConsumeReservedItemQuantity(id uint, quantity int, reason string) error {
+	var item model.Item
+	result := repo.db.Model(&item).
+		Clauses(clause.Returning{Columns: []clause.Column{{Name: "quantity"}}}).
+		Where("id = ? AND reserved >= ?", id, quantity).
+		UpdateColumns(map[string]interface{}{
+			"quantity": gorm.Expr("quantity - ?", quantity),
+			"reserved": gorm.Expr("reserved - ?", quantity),
+		})
+	if err := reservationResult(result, "consume"); err != nil {
+		return err
+	}
+
+	return repo.recordMovement(id, -quantity, item.Quantity, reason)
+}
+
+func reservationResult(result *gorm.DB, action string) error {
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrCheckConstraintViolated) {
+			return utils.ErrInsufficientQuantity
+		}
+		return fmt.Errorf("failed to %s item quantity: %w", action, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return utils.ErrInsufficientQuantity
+	}
+
+	return nil
+}
+
+// GetItemsInStorageOfCategory lists every item kept in the same storage as
+// the given category.
+func (repo *ItemRepository) GetItemsInStorageOfCategory(categoryID uint) ([]model.Item, error) {
+	storage := repo.db.Model(&model.Category{}).Select("storage_id").Where("id = ?", categoryID)
+	categories := repo.db.Model(&model.Category{}).Select("id").Where("storage_id = (?)", storage)
+
+	var items []model.Item
+	if err := repo.db.Where("category_id IN (?)", categories).Order("id").Find(&items).Error; err != nil {
+		return nil, fmt.Errorf("failed to get storage items: %w", err)
+	}
+
+	return items, nil
+}
+
 func (repo *ItemRepository) GetItems(limit, offset int) ([]model.Item, error) {
 	var items []model.Item
 
@@ -40,8 +185,10 @@ func (repo *ItemRepository) GetItems(limit, offset int) ([]model.Item, error) {
 	return items, nil
 }
 
+// UpdateItem saves an item's details. Its quantities are left alone, as they
+// only change through the stock ledger.
 func (repo *ItemRepository) UpdateItem(item model.Item) error {
-	if err := repo.db.Save(&item).Error; err != nil {
+	if err := repo.db.Omit("Quantity", "Reserved", clause.Associations).Save(&item).Error; err != nil {
 		return fmt.Errorf("failed to update item: %w", err)
 	}
 
@@ -56,14 +203,6 @@ func (repo *ItemRepository) DeleteItem(id string) error {
 	return nil
 }
 
-func (repo *ItemRepository) AddItem(item *model.Item) error {
-	if err := repo.db.Create(item).Error; err != nil {
-		return fmt.Errorf("failed to add item: %w", err)
-	}
-
-	return nil
-}
-
 func (repo *ItemRepository) GetItemByName(name string) (*model.Item, error) {
 	var item model.Item
 	if err := repo.db.Where("name = ?", name).First(&item).Error; err != nil {
@@ -73,6 +212,31 @@ func (repo *ItemRepository) GetItemByName(name string) (*model.Item, error) {
 	return &item, nil
 }
 
+func (repo *ItemRepository) GetItemMovements(itemID uint, limit, offset int) ([]model.ItemMovement, error) {
+	var movements []model.ItemMovement
+	if err := repo.db.Where("item_id = ?", itemID).Order("time DESC, id DESC").Limit(limit).Offset(offset).Find(&movements).Error; err != nil {
+		return nil, fmt.Errorf("failed to get item movements: %w", err)
+	}
+
+	return movements, nil
+}
+
+// GetItemBalanceAt returns the quantity an item had at the given time, taken
+// from the last ledger entry recorded up to then. Before the first entry,
+// whether the item did not exist yet or predates the ledger, the balance is
+// unknown and ErrItemBalanceNotFound is returned.
+func (repo *ItemRepository) GetItemBalanceAt(itemID uint, at time.Time) (int, error) {
+	var movements []model.ItemMovement
+	if err := repo.db.Where("item_id = ? AND time <= ?", itemID, at).Order("time DESC, id DESC").Limit(1).Find(&movements).Error; err != nil {
+		return 0, fmt.Errorf("failed to get item balance: %w", err)
+	}
+	if len(movements) == 0 {
+		return 0, utils.ErrItemBalanceNotFound
+	}
+
+	return movements[0].Balance, nil
+}
+
 func (repo *ItemRepository) ExportItems() ([]model.ExportItem, error) {
 	query := `
 		SELECT
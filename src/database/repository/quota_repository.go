@@ -0,0 +1,168 @@
+package repository
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gtihub.com/raditsoic/telkom-storage-ms/src/model"
+)
+
+// normalizedRequesterName folds the employee name of an inquiry the same way
+// the employee directory does, for requesters not linked to it.
+const normalizedRequesterName = `lower(regexp_replace(btrim(inquiry_transactions.employee_name), '\s+', ' ', 'g'))`
+
+type QuotaRepository struct {
+	db *gorm.DB
+}
+
+func NewQuotaRepository(db *gorm.DB) *QuotaRepository {
+	return &QuotaRepository{db: db}
+}
+
+// WithTx returns a copy of the repository bound to the given database transaction.
+func (repository *QuotaRepository) WithTx(tx *gorm.DB) *QuotaRepository {
+	return &QuotaRepository{db: tx}
+}
+
+func (repository *QuotaRepository) CreateQuota(quota *model.ConsumptionQuota) error {
+	if err := repository.db.Omit(clause.Associations).Create(quota).Error; err != nil {
+		return fmt.Errorf("failed to create quota: %w", err)
+	}
+
+	return nil
+}
+
+func (repository *QuotaRepository) GetQuotaByID(id uint) (*model.ConsumptionQuota, error) {
+	var quota model.ConsumptionQuota
+	if err := repository.db.First(&quota, id).Error; err != nil {
+		return nil, fmt.Errorf("failed to get quota: %w", err)
+	}
+
+	return &quota, nil
+}
+
+// GetQuotas lists the quotas, optionally only those of one employee or
+// department.
+func (repository *QuotaRepository) GetQuotas(employeeID *uint, department string) ([]model.ConsumptionQuota, error) {
+	query := repository.db.Model(&model.ConsumptionQuota{})
+	if employeeID != nil {
+		query = query.Where("employee_id = ?", *employeeID)
+	}
+	if department != "" {
+		query = query.Where("LOWER(department) = LOWER(?)", department)
+	}
+
+	var quotas []model.ConsumptionQuota
+	if err := query.Order("id").Find(&quotas).Error; err != nil {
+		return nil, fmt.Errorf("failed to get quotas: %w", err)
+	}
+
+	return quotas, nil
+}
+
+func (repository *QuotaRepository) UpdateQuota(quota *model.ConsumptionQuota) error {
+	if err := repository.db.Omit(clause.Associations).Save(quota).Error; err != nil {
+		return fmt.Errorf("failed to update quota: %w", err)
+	}
+
+	return nil
+}
+
+func (repository *QuotaRepository) DeleteQuota(id uint) error {
+	if err := repository.db.Delete(&model.ConsumptionQuota{}, id).Error; err != nil {
+		return fmt.Errorf("failed to delete quota: %w", err)
+	}
+
+	return nil
+}
+
+// GetApplicableQuotasForUpdate finds the quotas covering a requester and any
+// of the given items or categories, and locks them so that concurrent
+// requests against the same quota are checked one after the other.
+func (repository *QuotaRepository) GetApplicableQuotasForUpdate(employeeID *uint, department string, itemIDs, categoryIDs []uint) ([]model.ConsumptionQuota, error) {
+	query := repository.db.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("(item_id IN ? OR category_id IN ?)", itemIDs, categoryIDs)
+	if employeeID != nil {
+		query = query.Where("(employee_id = ? OR (employee_id IS NULL AND LOWER(department) = LOWER(?)))", *employeeID, department)
+	} else {
+		query = query.Where("employee_id IS NULL AND LOWER(department) = LOWER(?)", department)
+	}
+
+	var quotas []model.ConsumptionQuota
+	if err := query.Order("id").Find(&quotas).Error; err != nil {
+		return nil, fmt.Errorf("failed to get quotas: %w", err)
+	}
+
+	return quotas, nil
+}
+
+// quotaUsageQuery sums the inquiry lines in the given statuses that count
+// against a quota since the start of its period.
+func (repository *QuotaRepository) quotaUsageQuery(quota model.ConsumptionQuota, since time.Time, statuses []string) *gorm.DB {
+	query := repository.db.Model(&model.TransactionLine{}).
+		Joins("JOIN inquiry_transactions ON inquiry_transactions.id = transaction_lines.transaction_id AND transaction_lines.transaction_type = 'inquiry' AND inquiry_transactions.deleted_at IS NULL").
+		Where("inquiry_transactions.time >= ? AND transaction_lines.status IN ?", since, statuses)
+
+	if quota.EmployeeID != nil {
+		query = query.Where("inquiry_transactions.employee_id = ?", *quota.EmployeeID)
+	} else {
+		query = query.Where("LOWER(inquiry_transactions.employee_department) = LOWER(?)", quota.Department)
+	}
+
+	if quota.ItemID != nil {
+		query = query.Where("transaction_lines.item_id = ?", *quota.ItemID)
+	} else if quota.CategoryID != nil {
+		query = query.Where("transaction_lines.item_id IN (?)", repository.db.Model(&model.Item{}).Select("id").Where("category_id = ?", *quota.CategoryID))
+	}
+
+	return query
+}
+
+// GetQuotaUsage sums what has been taken against a quota since the start of
+// its period. For per-employee quotas only the given requester is counted,
+// by employee ID when linked to the directory and by name otherwise.
+func (repository *QuotaRepository) GetQuotaUsage(quota model.ConsumptionQuota, employeeID *uint, employeeName string, since time.Time, statuses []string) (int, error) {
+	query := repository.quotaUsageQuery(quota, since, statuses)
+	if quota.EmployeeID == nil && quota.PerEmployee {
+		if employeeID != nil {
+			query = query.Where("inquiry_transactions.employee_id = ?", *employeeID)
+		} else {
+			query = query.Where("inquiry_transactions.employee_id IS NULL AND "+normalizedRequesterName+" = ?", employeeName)
+		}
+	}
+
+	var used int
+	if err := query.Select("COALESCE(SUM(transaction_lines.quantity), 0)").Scan(&used).Error; err != nil {
+		return 0, fmt.Errorf("failed to get quota usage: %w", err)
+	}
+
+	return used, nil
+}
+
+// GetQuotaUsageByEmployee breaks the usage of a department quota down per
+// requester.
+func (repository *QuotaRepository) GetQuotaUsageByEmployee(quota model.ConsumptionQuota, since time.Time, statuses []string) ([]model.QuotaEmployeeUsage, error) {
+	var usage []model.QuotaEmployeeUsage
+	if err := repository.quotaUsageQuery(quota, since, statuses).
+		Select("MIN(inquiry_transactions.employee_id) AS employee_id, MIN(inquiry_transactions.employee_name) AS employee_name, SUM(transaction_lines.quantity) AS used").
+		Group("COALESCE('#' || inquiry_transactions.employee_id::text, " + normalizedRequesterName + ")").
+		Order("used DESC").
+		Scan(&usage).Error; err != nil {
+		return nil, fmt.Errorf("failed to get quota usage: %w", err)
+	}
+
+	return usage, nil
+}
+
+func (repository *QuotaRepository) CreateQuotaViolations(violations []model.QuotaViolation) error {
+	if len(violations) == 0 {
+		return nil
+	}
+	if err := repository.db.Omit(clause.Associations).Create(&violations).Error; err != nil {
+		return fmt.Errorf("failed to create quota violations: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,155 @@
+package repository
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gtihub.com/raditsoic/telkom-storage-ms/src/model"
+)
+
+type StockOpnameRepository struct {
+	db *gorm.DB
+}
+
+func NewStockOpnameRepository(db *gorm.DB) *StockOpnameRepository {
+	return &StockOpnameRepository{db: db}
+}
+
+// WithTx returns a copy of the repository bound to the given database transaction.
+func (repository *StockOpnameRepository) WithTx(tx *gorm.DB) *StockOpnameRepository {
+	return &StockOpnameRepository{db: tx}
+}
+
+// Transaction runs fn inside a database transaction.
+func (repository *StockOpnameRepository) Transaction(fn func(tx *gorm.DB) error) error {
+	return repository.db.Transaction(fn)
+}
+
+func (repository *StockOpnameRepository) CreateStockOpname(opname *model.StockOpname) error {
+	if err := repository.db.Create(opname).Error; err != nil {
+		return fmt.Errorf("failed to create stock opname: %w", err)
+	}
+
+	return nil
+}
+
+func (repository *StockOpnameRepository) GetStockOpnames() ([]model.StockOpname, error) {
+	var opnames []model.StockOpname
+	if err := repository.db.Order("opened_time DESC").Find(&opnames).Error; err != nil {
+		return nil, fmt.Errorf("failed to get stock opnames: %w", err)
+	}
+
+	return opnames, nil
+}
+
+func (repository *StockOpnameRepository) GetStockOpnameByID(id uint) (*model.StockOpname, error) {
+	var opname model.StockOpname
+	if err := repository.db.Preload("Storage").Preload("Counts", func(db *gorm.DB) *gorm.DB {
+		return db.Order("item_id, counter")
+	}).Preload("Report", func(db *gorm.DB) *gorm.DB {
+		return db.Order("item_id")
+	}).First(&opname, id).Error; err != nil {
+		return nil, fmt.Errorf("failed to get stock opname: %w", err)
+	}
+
+	return &opname, nil
+}
+
+// GetStockOpnameForUpdate loads a stock opname and locks its row until the
+// surrounding transaction ends.
+func (repository *StockOpnameRepository) GetStockOpnameForUpdate(id uint) (*model.StockOpname, error) {
+	var opname model.StockOpname
+	if err := repository.db.Clauses(clause.Locking{Strength: "UPDATE"}).First(&opname, id).Error; err != nil {
+		return nil, fmt.Errorf("failed to lock stock opname: %w", err)
+	}
+
+	return &opname, nil
+}
+
+func (repository *StockOpnameRepository) UpdateStockOpname(opname *model.StockOpname) error {
+	if err := repository.db.Omit(clause.Associations).Save(opname).Error; err != nil {
+		return fmt.Errorf("failed to update stock opname: %w", err)
+	}
+
+	return nil
+}
+
+// SaveStockOpnameCount records a counter's quantity of an item, replacing
+// what the same counter recorded for it before.
+func (repository *StockOpnameRepository) SaveStockOpnameCount(count *model.StockOpnameCount) error {
+	if err := repository.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "stock_opname_id"}, {Name: "item_id"}, {Name: "counter"}},
+		DoUpdates: clause.AssignmentColumns([]string{"quantity", "admin_id", "time"}),
+	}).Create(count).Error; err != nil {
+		return fmt.Errorf("failed to save stock opname count: %w", err)
+	}
+
+	return nil
+}
+
+func (repository *StockOpnameRepository) GetStockOpnameCounts(opnameID uint) ([]model.StockOpnameCount, error) {
+	var counts []model.StockOpnameCount
+	if err := repository.db.Where("stock_opname_id = ?", opnameID).Find(&counts).Error; err != nil {
+		return nil, fmt.Errorf("failed to get stock opname counts: %w", err)
+	}
+
+	return counts, nil
+}
+
+func (repository *StockOpnameRepository) CreateStockOpnameReport(lines []model.StockOpnameReportLine) error {
+	if len(lines) == 0 {
+		return nil
+	}
+	if err := repository.db.Create(&lines).Error; err != nil {
+		return fmt.Errorf("failed to create stock opname report: %w", err)
+	}
+
+	return nil
+}
+
+// GetStorageItems lists the items kept in the categories of a storage.
+func (repository *StockOpnameRepository) GetStorageItems(storageID int) ([]model.Item, error) {
+	var items []model.Item
+	if err := repository.db.
+		Where("category_id IN (?)", repository.db.Model(&model.Category{}).Select("id").Where("storage_id = ?", storageID)).
+		Order("shelf, name, id").
+		Find(&items).Error; err != nil {
+		return nil, fmt.Errorf("failed to get storage items: %w", err)
+	}
+
+	return items, nil
+}
+
+// IsCountingItems reports whether any of the given items sits in a storage
+// with an open stock opname.
+func (repository *StockOpnameRepository) IsCountingItems(itemIDs []uint) (bool, error) {
+	if len(itemIDs) == 0 {
+		return false, nil
+	}
+
+	var count int64
+	if err := repository.db.Model(&model.Item{}).
+		Joins("JOIN categories ON categories.id = items.category_id").
+		Joins("JOIN stock_opnames ON stock_opnames.storage_id = categories.storage_id AND stock_opnames.status = ?", "open").
+		Where("items.id IN ?", itemIDs).
+		Count(&count).Error; err != nil {
+		return false, fmt.Errorf("failed to check stock opnames: %w", err)
+	}
+
+	return count > 0, nil
+}
+
+// IsCountingCategory reports whether the storage of a category has an open
+// stock opname.
+func (repository *StockOpnameRepository) IsCountingCategory(categoryID uint) (bool, error) {
+	var count int64
+	if err := repository.db.Model(&model.Category{}).
+		Joins("JOIN stock_opnames ON stock_opnames.storage_id = categories.storage_id AND stock_opnames.status = ?", "open").
+		Where("categories.id = ?", categoryID).
+		Count(&count).Error; err != nil {
+		return false, fmt.Errorf("failed to check stock opnames: %w", err)
+	}
+
+	return count > 0, nil
+}
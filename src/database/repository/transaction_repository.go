@@ -6,6 +6,7 @@ import (
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"gtihub.com/raditsoic/telkom-storage-ms/src/model"
 )
 
@@ -17,6 +18,17 @@ func NewTransactionRepository(db *gorm.DB) *TransactionRepository {
 	return &TransactionRepository{db: db}
 }
 
+// WithTx returns a copy of the repository bound to the given database transaction.
+func (repository *TransactionRepository) WithTx(tx *gorm.DB) *TransactionRepository {
+	return &TransactionRepository{db: tx}
+}
+
+// Transaction runs fn inside a database transaction, committing when fn
+// returns nil and rolling back otherwise.
+func (repository *TransactionRepository) Transaction(fn func(tx *gorm.DB) error) error {
+	return repository.db.Transaction(fn)
+}
+
 func (repository *TransactionRepository) CreateLoanTransaction(loan model.LoanTransaction) (*model.LoanTransaction, error) {
 	if err := repository.db.Create(&loan).Error; err != nil {
 		return nil, fmt.Errorf("failed to create loan transaction: %w", err)
@@ -41,54 +53,335 @@ func (repository *TransactionRepository) CreateInsertionTransaction(insert *mode
 	return insert, nil
 }
 
-func (repository *TransactionRepository) GetLoanTransactions(limit, offset int) ([]model.LoanTransaction, error) {
+func (repository *TransactionRepository) CreateTransferTransaction(transfer *model.TransferTransaction) (*model.TransferTransaction, error) {
+	if err := repository.db.Create(transfer).Error; err != nil {
+		return nil, fmt.Errorf("failed to create transfer transaction: %w", err)
+	}
+
+	return transfer, nil
+}
+
+// transactionSortColumns maps the sort fields of the transaction listing to
+// their columns.
+var transactionSortColumns = map[string]string{
+	"time":     `"time"`,
+	"status":   "status",
+	"employee": "employee_name",
+}
+
+// filteredItemIDs builds a subquery of the IDs of items matching the item,
+// category and storage fields of the filter.
+func (repository *TransactionRepository) filteredItemIDs(filter model.TransactionFilter) *gorm.DB {
+	query := repository.db.Model(&model.Item{}).Select("id")
+	if filter.ItemID != nil {
+		query = query.Where("id = ?", *filter.ItemID)
+	}
+	if filter.CategoryID != nil {
+		query = query.Where("category_id = ?", *filter.CategoryID)
+	}
+	if filter.StorageID != nil {
+		query = query.Where("category_id IN (?)", repository.db.Model(&model.Category{}).Select("id").Where("storage_id = ?", *filter.StorageID))
+	}
+
+	return query
+}
+
+// filterTransactionFeed builds a query over the transaction feed narrowed
+// down by the filter.
+func (repository *TransactionRepository) filterTransactionFeed(filter model.TransactionFilter) *gorm.DB {
+	query := repository.db.Model(&model.TransactionFeedEntry{})
+	if filter.Deleted {
+		query = query.Where("deleted_at IS NOT NULL")
+	} else {
+		query = query.Where("deleted_at IS NULL")
+	}
+	if filter.Type != "" {
+		query = query.Where("transaction_type = ?", filter.Type)
+	}
+	if len(filter.Statuses) > 0 {
+		query = query.Where("status IN ?", filter.Statuses)
+	}
+	if filter.EmployeeName != "" {
+		query = query.Where("employee_name ILIKE ?", "%"+filter.EmployeeName+"%")
+	}
+	if filter.EmployeeID != nil {
+		query = query.Where("employee_id = ?", *filter.EmployeeID)
+	}
+	if filter.Department != "" {
+		query = query.Where("LOWER(employee_department) = LOWER(?)", filter.Department)
+	}
+	if filter.From != nil {
+		query = query.Where(`"time" >= ?`, *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where(`"time" <= ?`, *filter.To)
+	}
+	if filter.Search != "" {
+		query = query.Where("notes ILIKE ?", "%"+filter.Search+"%")
+	}
+
+	if filter.ItemID != nil || filter.CategoryID != nil || filter.StorageID != nil {
+		items := repository.filteredItemIDs(filter)
+		lines := repository.db.Model(&model.TransactionLine{}).Select("transaction_type, transaction_id").Where("item_id IN (?)", items)
+
+		if filter.ItemID != nil {
+			// Transfers match on either the source or the destination item.
+			transfers := repository.db.Model(&model.TransferTransaction{}).Unscoped().Select("id").Where("source_item_id IN (?) OR destination_item_id IN (?)", items, items)
+			query = query.Where("((transaction_type, id) IN (?) OR (transaction_type = 'insert' AND item_id IN (?)) OR (transaction_type = 'transfer' AND id IN (?)))", lines, items, transfers)
+		} else {
+			// Insertions that are not completed yet only know the category
+			// they were requested for.
+			categories := repository.db.Model(&model.Category{}).Select("id")
+			if filter.CategoryID != nil {
+				categories = categories.Where("id = ?", *filter.CategoryID)
+			}
+			if filter.StorageID != nil {
+				categories = categories.Where("storage_id = ?", *filter.StorageID)
+			}
+			// Transfers match on either end; the destination is kept as
+			// the requested category.
+			query = query.Where("((transaction_type, id) IN (?) OR (transaction_type = 'insert' AND (item_id IN (?) OR (item_id IS NULL AND item_request_category_id IN (?)))) OR (transaction_type = 'transfer' AND (item_id IN (?) OR item_request_category_id IN (?))))", lines, items, categories, items, categories)
+		}
+	}
+
+	return query.Session(&gorm.Session{})
+}
+
+// CountTransactionFeed counts the transactions of every type matching the
+// filter.
+func (repository *TransactionRepository) CountTransactionFeed(filter model.TransactionFilter) (int64, error) {
+	var total int64
+	if err := repository.filterTransactionFeed(filter).Count(&total).Error; err != nil {
+		return 0, fmt.Errorf("failed to count transactions: %w", err)
+	}
+
+	return total, nil
+}
+
+// GetTransactionFeed lists the transactions of every type matching the filter
+// in the requested order, newest first by default. When after is set the
+// listing continues past that entry and offset is ignored.
+func (repository *TransactionRepository) GetTransactionFeed(filter model.TransactionFilter, after *model.TransactionCursor, limit, offset int) ([]model.TransactionFeedEntry, error) {
+	column, ok := transactionSortColumns[filter.Sort]
+	if !ok {
+		column = transactionSortColumns["time"]
+	}
+	order, comparison := "DESC", "<"
+	if filter.Order == "asc" {
+		order, comparison = "ASC", ">"
+	}
+
+	query := repository.filterTransactionFeed(filter)
+	if after != nil {
+		var value interface{} = after.Value
+		if filter.Sort == "" || filter.Sort == "time" {
+			value = after.Time
+		}
+		query = query.Where(fmt.Sprintf("(%s, transaction_type, id) %s (?, ?, ?)", column, comparison), value, after.Type, after.ID)
+	} else {
+		query = query.Offset(offset)
+	}
+
+	var entries []model.TransactionFeedEntry
+	if err := query.Order(fmt.Sprintf("%s %s, transaction_type %s, id %s", column, order, order, order)).Limit(limit).Find(&entries).Error; err != nil {
+		return nil, fmt.Errorf("failed to get transactions: %w", err)
+	}
+
+	return entries, nil
+}
+
+// GetLoanTransactionsByIDs loads the loans the transaction feed points at.
+// The feed decides whether deleted transactions are listed, so they are
+// loaded whether they are deleted or not, as are the other types.
+func (repository *TransactionRepository) GetLoanTransactionsByIDs(ids []uint) ([]model.LoanTransaction, error) {
 	var loanTransactions []model.LoanTransaction
-	if err := repository.db.Preload("Item").Limit(limit).Offset(offset).Find(&loanTransactions).Error; err != nil {
+	if err := repository.db.Unscoped().Preload("Item").Preload("Lines.Item").Preload("Extensions").Where("id IN ?", ids).Find(&loanTransactions).Error; err != nil {
 		return nil, fmt.Errorf("failed to get loan transactions: %w", err)
 	}
 
 	return loanTransactions, nil
 }
 
-func (repository *TransactionRepository) GetInquiryTransactions(limit, offset int) ([]model.InquiryTransaction, error) {
+func (repository *TransactionRepository) GetInquiryTransactionsByIDs(ids []uint) ([]model.InquiryTransaction, error) {
 	var inquiryTransactions []model.InquiryTransaction
-	if err := repository.db.Preload("Item").Limit(limit).Offset(offset).Find(&inquiryTransactions).Error; err != nil {
+	if err := repository.db.Unscoped().Preload("Item").Preload("Lines.Item").Where("id IN ?", ids).Find(&inquiryTransactions).Error; err != nil {
 		return nil, fmt.Errorf("failed to get inquiry transactions: %w", err)
 	}
 
 	return inquiryTransactions, nil
 }
 
-func (repository *TransactionRepository) GetInsertionTransactions(limit, offset int) ([]model.InsertionTransaction, error) {
+func (repository *TransactionRepository) GetInsertionTransactionsByIDs(ids []uint) ([]model.InsertionTransaction, error) {
 	var insertTransactions []model.InsertionTransaction
-	if err := repository.db.Preload("Item").Limit(limit).Offset(offset).Find(&insertTransactions).Error; err != nil {
+	if err := repository.db.Unscoped().Preload("Item").Where("id IN ?", ids).Find(&insertTransactions).Error; err != nil {
 		return nil, fmt.Errorf("failed to get insert transactions: %w", err)
 	}
 
 	return insertTransactions, nil
 }
 
+func (repository *TransactionRepository) GetTransferTransactionsByIDs(ids []uint) ([]model.TransferTransaction, error) {
+	var transferTransactions []model.TransferTransaction
+	if err := repository.db.Unscoped().Preload("SourceItem").Preload("DestinationItem").Where("id IN ?", ids).Find(&transferTransactions).Error; err != nil {
+		return nil, fmt.Errorf("failed to get transfer transactions: %w", err)
+	}
+
+	return transferTransactions, nil
+}
+
+// loanHasUnitsOut matches loans with a handed out line that has not been
+// fully brought back.
+const loanHasUnitsOut = `EXISTS (
+	SELECT 1 FROM transaction_lines tl
+	WHERE tl.transaction_type = 'loan' AND tl.transaction_id = loan_transactions.id
+		AND tl.status = 'completed' AND tl.returned_quantity < tl.quantity
+)`
+
+// MarkOverdueLoans flags every loan that is past its return time with units
+// still out. Completed loans move to the overdue status and are returned.
+// Approved loans whose lines were handed out one by one keep their status, so
+// the remaining lines can still be decided, and only get their overdue time.
+func (repository *TransactionRepository) MarkOverdueLoans(now time.Time) ([]model.LoanTransaction, error) {
+	if err := repository.db.Model(&model.LoanTransaction{}).
+		Where("status = ? AND overdue_time IS NULL AND return_time > ? AND return_time < ?", "approved", time.Time{}, now).
+		Where(loanHasUnitsOut).
+		Update("overdue_time", now).Error; err != nil {
+		return nil, fmt.Errorf("failed to mark overdue loans: %w", err)
+	}
+
+	var loans []model.LoanTransaction
+	result := repository.db.Model(&loans).
+		Clauses(clause.Returning{Columns: []clause.Column{{Name: "id"}, {Name: "uuid"}}}).
+		Where("status = ? AND return_time > ? AND return_time < ?", "completed", time.Time{}, now).
+		Where(loanHasUnitsOut).
+		Updates(map[string]interface{}{
+			"status":       "overdue",
+			"overdue_time": gorm.Expr("COALESCE(overdue_time, ?)", now),
+		})
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to mark overdue loans: %w", result.Error)
+	}
+
+	return loans, nil
+}
+
+// GetOverdueLoanTransactions returns the overdue loans, including approved
+// loans that are overdue on the lines already handed out.
+func (repository *TransactionRepository) GetOverdueLoanTransactions() ([]model.LoanTransaction, error) {
+	var loanTransactions []model.LoanTransaction
+	if err := repository.db.Preload("Item").Preload("Lines.Item").
+		Where("status = ? OR (status = ? AND overdue_time IS NOT NULL AND "+loanHasUnitsOut+")", "overdue", "approved").
+		Order("return_time ASC").Find(&loanTransactions).Error; err != nil {
+		return nil, fmt.Errorf("failed to get overdue loan transactions: %w", err)
+	}
+
+	return loanTransactions, nil
+}
+
+func (repository *TransactionRepository) CreateLoanReturn(loanReturn *model.LoanReturn) error {
+	if err := repository.db.Create(loanReturn).Error; err != nil {
+		return fmt.Errorf("failed to create loan return: %w", err)
+	}
+
+	return nil
+}
+
+func (repository *TransactionRepository) CreateWriteOffs(writeOffs []model.WriteOff) error {
+	if len(writeOffs) == 0 {
+		return nil
+	}
+	if err := repository.db.Create(&writeOffs).Error; err != nil {
+		return fmt.Errorf("failed to create write-offs: %w", err)
+	}
+
+	return nil
+}
+
+func (repository *TransactionRepository) GetLoanReturns(loanID uint) ([]model.LoanReturn, error) {
+	var loanReturns []model.LoanReturn
+	if err := repository.db.Where("loan_transaction_id = ?", loanID).Order("time ASC").Find(&loanReturns).Error; err != nil {
+		return nil, fmt.Errorf("failed to get loan returns: %w", err)
+	}
+
+	return loanReturns, nil
+}
+
+func (repository *TransactionRepository) CreateLoanExtension(extension *model.LoanExtension) error {
+	if err := repository.db.Create(extension).Error; err != nil {
+		return fmt.Errorf("failed to create loan extension: %w", err)
+	}
+
+	return nil
+}
+
+func (repository *TransactionRepository) GetLoanExtensions(loanID uint) ([]model.LoanExtension, error) {
+	var extensions []model.LoanExtension
+	if err := repository.db.Where("loan_transaction_id = ?", loanID).Order("requested_time ASC").Find(&extensions).Error; err != nil {
+		return nil, fmt.Errorf("failed to get loan extensions: %w", err)
+	}
+
+	return extensions, nil
+}
+
+func (repository *TransactionRepository) GetLoanExtensionForUpdate(loanID, extensionID uint) (*model.LoanExtension, error) {
+	var extension model.LoanExtension
+	if err := repository.db.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND loan_transaction_id = ?", extensionID, loanID).
+		First(&extension).Error; err != nil {
+		return nil, fmt.Errorf("failed to get loan extension: %w", err)
+	}
+
+	return &extension, nil
+}
+
+func (repository *TransactionRepository) HasPendingLoanExtension(loanID uint) (bool, error) {
+	var count int64
+	if err := repository.db.Model(&model.LoanExtension{}).
+		Where("loan_transaction_id = ? AND status = ?", loanID, "pending").
+		Count(&count).Error; err != nil {
+		return false, fmt.Errorf("failed to check loan extensions: %w", err)
+	}
+
+	return count > 0, nil
+}
+
+func (repository *TransactionRepository) UpdateLoanExtension(extension *model.LoanExtension) error {
+	if err := repository.db.Save(extension).Error; err != nil {
+		return fmt.Errorf("failed to update loan extension: %w", err)
+	}
+
+	return nil
+}
+
 func (repository *TransactionRepository) UpdateLoanTransaction(loan *model.LoanTransaction) error {
-	if err := repository.db.Save(loan).Error; err != nil {
+	if err := repository.db.Omit(clause.Associations).Save(loan).Error; err != nil {
 		return fmt.Errorf("failed to update loan transaction: %w", err)
 	}
 	return nil
 }
 
 func (repository *TransactionRepository) UpdateInquiryTransaction(inquiry *model.InquiryTransaction) error {
-	if err := repository.db.Save(inquiry).Error; err != nil {
+	if err := repository.db.Omit(clause.Associations).Save(inquiry).Error; err != nil {
 		return fmt.Errorf("failed to update inquiry transaction: %w", err)
 	}
 	return nil
 }
 
 func (repository *TransactionRepository) UpdateInsertionTransaction(insert *model.InsertionTransaction) error {
-	if err := repository.db.Save(insert).Error; err != nil {
+	if err := repository.db.Omit(clause.Associations).Save(insert).Error; err != nil {
 		return fmt.Errorf("failed to update insertion transaction: %w", err)
 	}
 	return nil
 }
 
+func (repository *TransactionRepository) UpdateTransferTransaction(transfer *model.TransferTransaction) error {
+	if err := repository.db.Omit(clause.Associations).Save(transfer).Error; err != nil {
+		return fmt.Errorf("failed to update transfer transaction: %w", err)
+	}
+
+	return nil
+}
+
 func (repository *TransactionRepository) GetInsertionTransactionByUUID(uuid uuid.UUID) (*model.InsertionTransaction, error) {
 	var insert model.InsertionTransaction
 	if err := repository.db.Preload("Item").Where("uuid = ?", uuid).First(&insert).Error; err != nil {
@@ -98,9 +391,18 @@ func (repository *TransactionRepository) GetInsertionTransactionByUUID(uuid uuid
 	return &insert, nil
 }
 
+func (repository *TransactionRepository) GetTransferTransactionByUUID(uuid uuid.UUID) (*model.TransferTransaction, error) {
+	var transfer model.TransferTransaction
+	if err := repository.db.Preload("SourceItem").Preload("DestinationItem").Where("uuid = ?", uuid).First(&transfer).Error; err != nil {
+		return nil, fmt.Errorf("failed to get transfer transaction: %w", err)
+	}
+
+	return &transfer, nil
+}
+
 func (repository *TransactionRepository) GetLoanTransactionByUUID(uuid uuid.UUID) (*model.LoanTransaction, error) {
 	var loan model.LoanTransaction
-	if err := repository.db.Preload("Item").Where("uuid = ?", uuid).First(&loan).Error; err != nil {
+	if err := repository.db.Preload("Item").Preload("Lines.Item").Where("uuid = ?", uuid).First(&loan).Error; err != nil {
 		return nil, fmt.Errorf("failed to get loan transaction: %w", err)
 	}
 
@@ -109,38 +411,302 @@ func (repository *TransactionRepository) GetLoanTransactionByUUID(uuid uuid.UUID
 
 func (repository *TransactionRepository) GetInquiryTransactionByUUID(uuid uuid.UUID) (*model.InquiryTransaction, error) {
 	var inquiry model.InquiryTransaction
-	if err := repository.db.Preload("Item").Where("uuid = ?", uuid).First(&inquiry).Error; err != nil {
+	if err := repository.db.Preload("Item").Preload("Lines.Item").Where("uuid = ?", uuid).First(&inquiry).Error; err != nil {
 		return nil, fmt.Errorf("failed to get inquiry transaction: %w", err)
 	}
 
 	return &inquiry, nil
 }
 
-func (repository *TransactionRepository) DeleteLoanTransactionByUUID(uuid uuid.UUID) error {
-	if err := repository.db.Where("uuid = ?", uuid).Delete(&model.LoanTransaction{}).Error; err != nil {
-		return fmt.Errorf("failed to delete loan transaction: %w", err)
+func (repository *TransactionRepository) GetLoanTransactionByUUIDForUpdate(uuid uuid.UUID) (*model.LoanTransaction, error) {
+	var loan model.LoanTransaction
+	if err := repository.db.Clauses(clause.Locking{Strength: "UPDATE"}).Where("uuid = ?", uuid).First(&loan).Error; err != nil {
+		return nil, fmt.Errorf("failed to lock loan transaction: %w", err)
+	}
+
+	return &loan, nil
+}
+
+func (repository *TransactionRepository) GetInquiryTransactionByUUIDForUpdate(uuid uuid.UUID) (*model.InquiryTransaction, error) {
+	var inquiry model.InquiryTransaction
+	if err := repository.db.Clauses(clause.Locking{Strength: "UPDATE"}).Where("uuid = ?", uuid).First(&inquiry).Error; err != nil {
+		return nil, fmt.Errorf("failed to lock inquiry transaction: %w", err)
+	}
+
+	return &inquiry, nil
+}
+
+func (repository *TransactionRepository) GetInsertionTransactionByUUIDForUpdate(uuid uuid.UUID) (*model.InsertionTransaction, error) {
+	var insert model.InsertionTransaction
+	if err := repository.db.Clauses(clause.Locking{Strength: "UPDATE"}).Where("uuid = ?", uuid).First(&insert).Error; err != nil {
+		return nil, fmt.Errorf("failed to lock insertion transaction: %w", err)
+	}
+
+	return &insert, nil
+}
+
+func (repository *TransactionRepository) GetTransferTransactionByUUIDForUpdate(uuid uuid.UUID) (*model.TransferTransaction, error) {
+	var transfer model.TransferTransaction
+	if err := repository.db.Clauses(clause.Locking{Strength: "UPDATE"}).Where("uuid = ?", uuid).First(&transfer).Error; err != nil {
+		return nil, fmt.Errorf("failed to lock transfer transaction: %w", err)
+	}
+
+	return &transfer, nil
+}
+
+// GetTransactionLinesForUpdate locks and returns the lines of a loan or
+// inquiry, ordered by item so concurrent requests lock items in the same order.
+func (repository *TransactionRepository) GetTransactionLinesForUpdate(transactionType string, transactionID uint) ([]model.TransactionLine, error) {
+	var lines []model.TransactionLine
+	if err := repository.db.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("transaction_type = ? AND transaction_id = ?", transactionType, transactionID).
+		Order("item_id ASC, id ASC").
+		Find(&lines).Error; err != nil {
+		return nil, fmt.Errorf("failed to lock transaction lines: %w", err)
+	}
+
+	return lines, nil
+}
+
+func (repository *TransactionRepository) UpdateTransactionLine(line *model.TransactionLine) error {
+	if err := repository.db.Omit(clause.Associations).Save(line).Error; err != nil {
+		return fmt.Errorf("failed to update transaction line: %w", err)
 	}
 
 	return nil
 }
 
-func (repository *TransactionRepository) DeleteInquiryTransactionByUUID(uuid uuid.UUID) error {
-	if err := repository.db.Where("uuid = ?", uuid).Delete(&model.InquiryTransaction{}).Error; err != nil {
-		return fmt.Errorf("failed to delete inquiry transaction: %w", err)
+func (repository *TransactionRepository) deleteTransactionLines(transactionType string, transactionID uint) error {
+	if err := repository.db.Where("transaction_type = ? AND transaction_id = ?", transactionType, transactionID).
+		Delete(&model.TransactionLine{}).Error; err != nil {
+		return fmt.Errorf("failed to delete transaction lines: %w", err)
+	}
+
+	return nil
+}
+
+func (repository *TransactionRepository) CreateTransactionApprovals(approvals []model.TransactionApproval) error {
+	if len(approvals) == 0 {
+		return nil
+	}
+	if err := repository.db.Create(&approvals).Error; err != nil {
+		return fmt.Errorf("failed to create transaction approvals: %w", err)
 	}
 
 	return nil
 }
 
+func (repository *TransactionRepository) GetTransactionApprovals(transactionType string, transactionID uint) ([]model.TransactionApproval, error) {
+	var approvals []model.TransactionApproval
+	if err := repository.db.Where("transaction_type = ? AND transaction_id = ?", transactionType, transactionID).
+		Order("position ASC, id ASC").
+		Find(&approvals).Error; err != nil {
+		return nil, fmt.Errorf("failed to get transaction approvals: %w", err)
+	}
+
+	return approvals, nil
+}
+
+func (repository *TransactionRepository) GetTransactionApprovalsForUpdate(transactionType string, transactionID uint) ([]model.TransactionApproval, error) {
+	var approvals []model.TransactionApproval
+	if err := repository.db.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("transaction_type = ? AND transaction_id = ?", transactionType, transactionID).
+		Order("position ASC, id ASC").
+		Find(&approvals).Error; err != nil {
+		return nil, fmt.Errorf("failed to lock transaction approvals: %w", err)
+	}
+
+	return approvals, nil
+}
+
+// HasPendingApprovals reports whether a loan or inquiry still has approval
+// steps waiting for a decision.
+func (repository *TransactionRepository) HasPendingApprovals(transactionType string, transactionID uint) (bool, error) {
+	var count int64
+	if err := repository.db.Model(&model.TransactionApproval{}).
+		Where("transaction_type = ? AND transaction_id = ? AND status = ?", transactionType, transactionID, "pending").
+		Count(&count).Error; err != nil {
+		return false, fmt.Errorf("failed to check transaction approvals: %w", err)
+	}
+
+	return count > 0, nil
+}
+
+func (repository *TransactionRepository) UpdateTransactionApproval(approval *model.TransactionApproval) error {
+	if err := repository.db.Save(approval).Error; err != nil {
+		return fmt.Errorf("failed to update transaction approval: %w", err)
+	}
+
+	return nil
+}
+
+func (repository *TransactionRepository) deleteTransactionApprovals(transactionType string, transactionID uint) error {
+	if err := repository.db.Where("transaction_type = ? AND transaction_id = ?", transactionType, transactionID).
+		Delete(&model.TransactionApproval{}).Error; err != nil {
+		return fmt.Errorf("failed to delete transaction approvals: %w", err)
+	}
+
+	return nil
+}
+
+func (repository *TransactionRepository) CreateTransactionEvent(event *model.TransactionEvent) error {
+	if err := repository.db.Create(event).Error; err != nil {
+		return fmt.Errorf("failed to create transaction event: %w", err)
+	}
+
+	return nil
+}
+
+func (repository *TransactionRepository) GetTransactionEvents(transactionType string, transactionUUID uuid.UUID) ([]model.TransactionEvent, error) {
+	var events []model.TransactionEvent
+	if err := repository.db.Where("transaction_type = ? AND transaction_uuid = ?", transactionType, transactionUUID).
+		Order("time ASC, id ASC").
+		Find(&events).Error; err != nil {
+		return nil, fmt.Errorf("failed to get transaction events: %w", err)
+	}
+
+	return events, nil
+}
+
+// transactionModels maps each transaction type to its model, for the queries
+// shared by every type.
+var transactionModels = map[string]func() interface{}{
+	"loan":     func() interface{} { return &model.LoanTransaction{} },
+	"inquiry":  func() interface{} { return &model.InquiryTransaction{} },
+	"insert":   func() interface{} { return &model.InsertionTransaction{} },
+	"transfer": func() interface{} { return &model.TransferTransaction{} },
+}
+
+// SoftDeleteTransaction moves a transaction to the trash. It is left out of
+// every lookup and listing until it is restored.
+func (repository *TransactionRepository) SoftDeleteTransaction(transactionType string, id uint, adminID *uint, reason string, now time.Time) error {
+	if err := repository.db.Model(transactionModels[transactionType]()).Where("id = ?", id).Updates(map[string]interface{}{
+		"deleted_at":    now,
+		"deleted_by":    adminID,
+		"delete_reason": reason,
+	}).Error; err != nil {
+		return fmt.Errorf("failed to delete %s transaction: %w", transactionType, err)
+	}
+
+	return nil
+}
+
+// RestoreTransaction takes a transaction out of the trash.
+func (repository *TransactionRepository) RestoreTransaction(transactionType string, id uint) error {
+	if err := repository.db.Unscoped().Model(transactionModels[transactionType]()).Where("id = ?", id).Updates(map[string]interface{}{
+		"deleted_at":    nil,
+		"deleted_by":    nil,
+		"delete_reason": "",
+	}).Error; err != nil {
+		return fmt.Errorf("failed to restore %s transaction: %w", transactionType, err)
+	}
+
+	return nil
+}
+
+func (repository *TransactionRepository) GetDeletedLoanTransactionByUUIDForUpdate(uuid uuid.UUID) (*model.LoanTransaction, error) {
+	var loan model.LoanTransaction
+	if err := repository.db.Unscoped().Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("uuid = ? AND deleted_at IS NOT NULL", uuid).
+		First(&loan).Error; err != nil {
+		return nil, fmt.Errorf("failed to get deleted loan transaction: %w", err)
+	}
+
+	return &loan, nil
+}
+
+func (repository *TransactionRepository) GetDeletedInquiryTransactionByUUIDForUpdate(uuid uuid.UUID) (*model.InquiryTransaction, error) {
+	var inquiry model.InquiryTransaction
+	if err := repository.db.Unscoped().Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("uuid = ? AND deleted_at IS NOT NULL", uuid).
+		First(&inquiry).Error; err != nil {
+		return nil, fmt.Errorf("failed to get deleted inquiry transaction: %w", err)
+	}
+
+	return &inquiry, nil
+}
+
+func (repository *TransactionRepository) GetDeletedInsertionTransactionByUUIDForUpdate(uuid uuid.UUID) (*model.InsertionTransaction, error) {
+	var insertion model.InsertionTransaction
+	if err := repository.db.Unscoped().Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("uuid = ? AND deleted_at IS NOT NULL", uuid).
+		First(&insertion).Error; err != nil {
+		return nil, fmt.Errorf("failed to get deleted insertion transaction: %w", err)
+	}
+
+	return &insertion, nil
+}
+
+func (repository *TransactionRepository) GetDeletedTransferTransactionByUUIDForUpdate(uuid uuid.UUID) (*model.TransferTransaction, error) {
+	var transfer model.TransferTransaction
+	if err := repository.db.Unscoped().Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("uuid = ? AND deleted_at IS NOT NULL", uuid).
+		First(&transfer).Error; err != nil {
+		return nil, fmt.Errorf("failed to get deleted transfer transaction: %w", err)
+	}
+
+	return &transfer, nil
+}
+
+func (repository *TransactionRepository) DeleteLoanTransactionByUUID(uuid uuid.UUID) error {
+	return repository.db.Transaction(func(tx *gorm.DB) error {
+		var loan model.LoanTransaction
+		if err := tx.Unscoped().Where("uuid = ?", uuid).First(&loan).Error; err != nil {
+			return fmt.Errorf("failed to delete loan transaction: %w", err)
+		}
+		if err := repository.WithTx(tx).deleteTransactionLines("loan", loan.ID); err != nil {
+			return err
+		}
+		if err := repository.WithTx(tx).deleteTransactionApprovals("loan", loan.ID); err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Delete(&loan).Error; err != nil {
+			return fmt.Errorf("failed to delete loan transaction: %w", err)
+		}
+
+		return nil
+	})
+}
+
+func (repository *TransactionRepository) DeleteInquiryTransactionByUUID(uuid uuid.UUID) error {
+	return repository.db.Transaction(func(tx *gorm.DB) error {
+		var inquiry model.InquiryTransaction
+		if err := tx.Unscoped().Where("uuid = ?", uuid).First(&inquiry).Error; err != nil {
+			return fmt.Errorf("failed to delete inquiry transaction: %w", err)
+		}
+		if err := repository.WithTx(tx).deleteTransactionLines("inquiry", inquiry.ID); err != nil {
+			return err
+		}
+		if err := repository.WithTx(tx).deleteTransactionApprovals("inquiry", inquiry.ID); err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Delete(&inquiry).Error; err != nil {
+			return fmt.Errorf("failed to delete inquiry transaction: %w", err)
+		}
+
+		return nil
+	})
+}
+
 func (repository *TransactionRepository) DeleteInsertionTransactionByUUID(uuid uuid.UUID) error {
-	if err := repository.db.Where("uuid = ?", uuid).Delete(&model.InsertionTransaction{}).Error; err != nil {
+	if err := repository.db.Unscoped().Where("uuid = ?", uuid).Delete(&model.InsertionTransaction{}).Error; err != nil {
 		return fmt.Errorf("failed to delete insertion transaction: %w", err)
 	}
 
 	return nil
 }
 
-func (repository *TransactionRepository) ExportTransactions(from, to time.Time) ([]model.ExportTransaction, error) {
+func (repository *TransactionRepository) DeleteTransferTransactionByUUID(uuid uuid.UUID) error {
+	if err := repository.db.Unscoped().Where("uuid = ?", uuid).Delete(&model.TransferTransaction{}).Error; err != nil {
+		return fmt.Errorf("failed to delete transfer transaction: %w", err)
+	}
+
+	return nil
+}
+
+// ExportTransactions lists the transactions made between from and to, one
+// row per line. Deleted transactions are only included when asked for.
+func (repository *TransactionRepository) ExportTransactions(from, to time.Time, includeDeleted bool) ([]model.ExportTransaction, error) {
 	query := `
 		SELECT 
 			'LoanTransaction' AS transaction_type,
@@ -151,20 +717,27 @@ func (repository *TransactionRepository) ExportTransactions(from, to time.Time)
 			lt.employee_position,
 			c.name AS category_name,
 			i.name AS item_name,
-			lt.quantity,
+			COALESCE(tl.quantity, lt.quantity) AS quantity,
 			lt.status,
 			lt.notes,
 			lt.time,
-			lt.item_id,
+			COALESCE(tl.item_id, lt.item_id) AS item_id,
 			lt.loan_time,
 			lt.return_time,
 			lt.completed_time,
 			lt.returned_time,
-			NULL::TEXT AS image
+			lt.status_reason,
+			lt.admin_note,
+			NULL::TEXT AS image,
+			NULL::TEXT AS destination_storage_name,
+			NULL::TEXT AS destination_category_name,
+			NULL::TEXT AS destination_shelf,
+			NULL::BIGINT AS destination_item_id
 		FROM loan_transactions lt
-		LEFT JOIN items i ON lt.item_id = i.id
+		LEFT JOIN transaction_lines tl ON tl.transaction_type = 'loan' AND tl.transaction_id = lt.id
+		LEFT JOIN items i ON COALESCE(tl.item_id, lt.item_id) = i.id
 		LEFT JOIN categories c ON i.category_id = c.id
-		WHERE lt.time BETWEEN ? AND ?
+		WHERE lt.time BETWEEN ? AND ? AND (? OR lt.deleted_at IS NULL)
 
 		UNION ALL
 
@@ -177,20 +750,27 @@ func (repository *TransactionRepository) ExportTransactions(from, to time.Time)
 			it.employee_position,
 			c.name AS category_name,
 			i.name AS item_name,
-			it.quantity,
+			COALESCE(tl.quantity, it.quantity) AS quantity,
 			it.status,
 			it.notes,
 			it.time,
-			it.item_id,
+			COALESCE(tl.item_id, it.item_id) AS item_id,
 			NULL,
 			NULL,
 			it.completed_time,
 			NULL,
-			NULL::TEXT AS image
+			it.status_reason,
+			it.admin_note,
+			NULL::TEXT AS image,
+			NULL::TEXT,
+			NULL::TEXT,
+			NULL::TEXT,
+			NULL::BIGINT
 		FROM inquiry_transactions it
-		LEFT JOIN items i ON it.item_id = i.id
+		LEFT JOIN transaction_lines tl ON tl.transaction_type = 'inquiry' AND tl.transaction_id = it.id
+		LEFT JOIN items i ON COALESCE(tl.item_id, it.item_id) = i.id
 		LEFT JOIN categories c ON i.category_id = c.id
-		WHERE it.time BETWEEN ? AND ?
+		WHERE it.time BETWEEN ? AND ? AND (? OR it.deleted_at IS NULL)
 
 		UNION ALL
 
@@ -212,15 +792,55 @@ func (repository *TransactionRepository) ExportTransactions(from, to time.Time)
 			NULL,
 			int.completed_time,
 			NULL,
-			ENCODE(int.image, 'base64') AS image
+			int.status_reason,
+			int.admin_note,
+			ENCODE(int.image, 'base64') AS image,
+			NULL::TEXT,
+			NULL::TEXT,
+			NULL::TEXT,
+			NULL::BIGINT
 		FROM insertion_transactions int
 		LEFT JOIN items i ON int.item_id = i.id
 		LEFT JOIN categories c ON i.category_id = c.id
-		WHERE int.time BETWEEN ? AND ?
+		WHERE int.time BETWEEN ? AND ? AND (? OR int.deleted_at IS NULL)
+
+		UNION ALL
+
+		SELECT
+			'TransferTransaction' AS transaction_type,
+			tt.id,
+			tt.uuid,
+			'',
+			'',
+			'',
+			c.name AS category_name,
+			i.name AS item_name,
+			tt.quantity,
+			tt.status,
+			tt.notes,
+			tt.time,
+			tt.source_item_id,
+			NULL,
+			NULL,
+			tt.completed_time,
+			NULL,
+			tt.status_reason,
+			tt.admin_note,
+			NULL::TEXT AS image,
+			ds.name,
+			dc.name,
+			tt.destination_shelf,
+			tt.destination_item_id
+		FROM transfer_transactions tt
+		LEFT JOIN items i ON tt.source_item_id = i.id
+		LEFT JOIN categories c ON i.category_id = c.id
+		LEFT JOIN storages ds ON tt.destination_storage_id = ds.id
+		LEFT JOIN categories dc ON tt.destination_category_id = dc.id
+		WHERE tt.time BETWEEN ? AND ? AND (? OR tt.deleted_at IS NULL)
 	`
 
 	var results []model.ExportTransaction
-	if err := repository.db.Raw(query, from, to, from, to, from, to).Scan(&results).Error; err != nil {
+	if err := repository.db.Raw(query, from, to, includeDeleted, from, to, includeDeleted, from, to, includeDeleted, from, to, includeDeleted).Scan(&results).Error; err != nil {
 		return nil, fmt.Errorf("failed to execute combined transactions query: %w", err)
 	}
 
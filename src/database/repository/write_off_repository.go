@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+	"gtihub.com/raditsoic/telkom-storage-ms/src/model"
+)
+
+// normalizedBorrowerName matches write-offs of borrowers that are not linked
+// to the employee directory by name.
+const normalizedBorrowerName = `lower(regexp_replace(btrim(write_offs.employee_name), '\s+', ' ', 'g'))`
+
+type WriteOffRepository struct {
+	db *gorm.DB
+}
+
+func NewWriteOffRepository(db *gorm.DB) *WriteOffRepository {
+	return &WriteOffRepository{db: db}
+}
+
+func (repository *WriteOffRepository) filterWriteOffs(filter model.WriteOffFilter) *gorm.DB {
+	query := repository.db.Model(&model.WriteOff{})
+	if filter.EmployeeID != nil {
+		query = query.Where("write_offs.employee_id = ?", *filter.EmployeeID)
+	}
+	if filter.Department != "" {
+		query = query.Where("LOWER(write_offs.employee_department) = LOWER(?)", filter.Department)
+	}
+	if filter.Condition != "" {
+		query = query.Where("write_offs.condition = ?", filter.Condition)
+	}
+	if filter.From != nil {
+		query = query.Where("write_offs.time >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("write_offs.time <= ?", *filter.To)
+	}
+
+	return query
+}
+
+func (repository *WriteOffRepository) GetWriteOffs(filter model.WriteOffFilter, limit, offset int) ([]model.WriteOff, int64, error) {
+	var total int64
+	if err := repository.filterWriteOffs(filter).Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count write-offs: %w", err)
+	}
+
+	var writeOffs []model.WriteOff
+	if err := repository.filterWriteOffs(filter).Preload("Item").
+		Order("time DESC, id DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&writeOffs).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to get write-offs: %w", err)
+	}
+
+	return writeOffs, total, nil
+}
+
+// GetLiabilityByEmployee sums the write-offs per borrower, by employee ID
+// when linked to the directory and by name otherwise.
+func (repository *WriteOffRepository) GetLiabilityByEmployee(filter model.WriteOffFilter) ([]model.LiabilityEntry, error) {
+	var entries []model.LiabilityEntry
+	if err := repository.filterWriteOffs(filter).
+		Select(`MIN(write_offs.employee_id) AS employee_id, MIN(write_offs.employee_name) AS employee_name,
+			MIN(write_offs.employee_department) AS department,
+			COALESCE(SUM(write_offs.quantity) FILTER (WHERE write_offs.condition = 'damaged'), 0) AS damaged,
+			COALESCE(SUM(write_offs.quantity) FILTER (WHERE write_offs.condition = 'lost'), 0) AS lost,
+			SUM(write_offs.quantity) AS total, COUNT(*) AS write_offs`).
+		Group("COALESCE('#' || write_offs.employee_id::text, " + normalizedBorrowerName + ")").
+		Order("total DESC").
+		Scan(&entries).Error; err != nil {
+		return nil, fmt.Errorf("failed to get liability report: %w", err)
+	}
+
+	return entries, nil
+}
+
+// GetLiabilityByDepartment sums the write-offs per department of the
+// borrowers.
+func (repository *WriteOffRepository) GetLiabilityByDepartment(filter model.WriteOffFilter) ([]model.LiabilityEntry, error) {
+	var entries []model.LiabilityEntry
+	if err := repository.filterWriteOffs(filter).
+		Select(`MIN(write_offs.employee_department) AS department,
+			COALESCE(SUM(write_offs.quantity) FILTER (WHERE write_offs.condition = 'damaged'), 0) AS damaged,
+			COALESCE(SUM(write_offs.quantity) FILTER (WHERE write_offs.condition = 'lost'), 0) AS lost,
+			SUM(write_offs.quantity) AS total, COUNT(*) AS write_offs`).
+		Group("LOWER(btrim(write_offs.employee_department))").
+		Order("total DESC").
+		Scan(&entries).Error; err != nil {
+		return nil, fmt.Errorf("failed to get liability report: %w", err)
+	}
+
+	return entries, nil
+}
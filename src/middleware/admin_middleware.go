@@ -3,6 +3,7 @@ package middleware
 import (
 	"context"
 	"net/http"
+	"strconv"
 	"strings"
 
 	"gtihub.com/raditsoic/telkom-storage-ms/src/utils"
@@ -38,3 +39,20 @@ func AuthMiddleware(jwtUtils *utils.JWTUtils, next http.Handler) http.Handler {
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
+
+// AdminIDFromContext returns the ID of the admin whose token authorized the
+// request, or nil when the request is not authenticated.
+func AdminIDFromContext(ctx context.Context) *uint {
+	claims, ok := ctx.Value(AdminContextKey).(*utils.Claims)
+	if !ok {
+		return nil
+	}
+
+	id, err := strconv.ParseUint(claims.ID, 10, 32)
+	if err != nil {
+		return nil
+	}
+
+	adminID := uint(id)
+	return &adminID
+}
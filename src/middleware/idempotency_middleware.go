@@ -0,0 +1,184 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"log"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"strings"
+
+	"gtihub.com/raditsoic/telkom-storage-ms/src/service"
+	"gtihub.com/raditsoic/telkom-storage-ms/src/utils"
+)
+
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+const maxIdempotencyKeyLength = 255
+
+// IdempotencyMiddleware replays the stored response when a request is retried
+// with the same Idempotency-Key header and body. Requests without the header
+// pass through untouched. Keys are scoped to the signed-in admin, and for
+// anonymous requests to the request body, so clients picking the same key do
+// not see each other's responses.
+func IdempotencyMiddleware(idempotencyService *service.IdempotencyService, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := strings.TrimSpace(r.Header.Get(IdempotencyKeyHeader))
+		if key == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			http.Error(w, "Idempotency key is too long", http.StatusBadRequest)
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		requestHash, err := hashRequestBody(r.Header.Get("Content-Type"), body)
+		if err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		endpoint := r.Method + " " + r.URL.Path
+		client := idempotencyClient(r, requestHash)
+		stored, err := idempotencyService.BeginIdempotentRequest(endpoint, client, key, requestHash)
+		if err != nil {
+			if errors.Is(err, utils.ErrIdempotencyKeyReused) {
+				http.Error(w, "Idempotency key was already used with a different request body", http.StatusUnprocessableEntity)
+				return
+			}
+			if errors.Is(err, utils.ErrIdempotencyKeyInProgress) {
+				http.Error(w, "A request with this idempotency key is still being processed", http.StatusConflict)
+				return
+			}
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		if stored != nil {
+			if stored.ContentType != "" {
+				w.Header().Set("Content-Type", stored.ContentType)
+			}
+			w.Header().Set("Idempotent-Replayed", "true")
+			w.WriteHeader(stored.StatusCode)
+			if _, err := w.Write(stored.Response); err != nil {
+				log.Printf("Error replaying idempotent response: %v", err)
+			}
+			return
+		}
+
+		// A handler that panics never completes the key, so release it
+		// rather than leaving it in progress for good.
+		completed := false
+		defer func() {
+			if completed {
+				return
+			}
+			if err := idempotencyService.ReleaseIdempotentRequest(endpoint, client, key); err != nil {
+				log.Printf("Error releasing idempotency key: %v", err)
+			}
+		}()
+
+		recorder := &responseRecorder{ResponseWriter: w}
+		next.ServeHTTP(recorder, r)
+
+		statusCode := recorder.statusCode
+		if statusCode == 0 {
+			statusCode = http.StatusOK
+		}
+		if err := idempotencyService.CompleteIdempotentRequest(endpoint, client, key, statusCode, w.Header().Get("Content-Type"), recorder.body.Bytes()); err != nil {
+			log.Printf("Error storing idempotent response: %v", err)
+			return
+		}
+		completed = true
+	})
+}
+
+// idempotencyClient names who a key belongs to. Anonymous requesters cannot
+// be told apart, so their key is scoped to the body they sent and reusing it
+// with another body starts a new request.
+func idempotencyClient(r *http.Request, requestHash string) string {
+	if adminID := AdminIDFromContext(r.Context()); adminID != nil {
+		return fmt.Sprintf("admin %d", *adminID)
+	}
+
+	return "anonymous " + requestHash
+}
+
+// responseRecorder passes a response through while keeping a copy of it.
+type responseRecorder struct {
+	http.ResponseWriter
+	statusCode int
+	body       bytes.Buffer
+}
+
+func (recorder *responseRecorder) WriteHeader(statusCode int) {
+	if recorder.statusCode == 0 {
+		recorder.statusCode = statusCode
+	}
+	recorder.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (recorder *responseRecorder) Write(data []byte) (int, error) {
+	if recorder.statusCode == 0 {
+		recorder.statusCode = http.StatusOK
+	}
+	recorder.body.Write(data)
+	return recorder.ResponseWriter.Write(data)
+}
+
+// hashRequestBody fingerprints a request body. Multipart bodies are hashed
+// part by part, because a retried form gets a new boundary every time.
+func hashRequestBody(contentType string, body []byte) (string, error) {
+	digest := sha256.New()
+
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil || !strings.HasPrefix(mediaType, "multipart/") {
+		digest.Write(body)
+		return hex.EncodeToString(digest.Sum(nil)), nil
+	}
+
+	reader := multipart.NewReader(bytes.NewReader(body), params["boundary"])
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+
+		content, err := io.ReadAll(part)
+		if err != nil {
+			return "", err
+		}
+		writeHashField(digest, []byte(part.FormName()))
+		writeHashField(digest, []byte(part.FileName()))
+		writeHashField(digest, content)
+	}
+
+	return hex.EncodeToString(digest.Sum(nil)), nil
+}
+
+// writeHashField writes a length-prefixed field so that adjacent fields
+// cannot run into each other.
+func writeHashField(digest hash.Hash, field []byte) {
+	var length [8]byte
+	binary.BigEndian.PutUint64(length[:], uint64(len(field)))
+	digest.Write(length[:])
+	digest.Write(field)
+}
@@ -0,0 +1,97 @@
+package model
+
+import "time"
+
+// ApprovalChain requires a loan or inquiry to be approved by a sequence of
+// approver roles before it counts as approved. A chain applies to requests
+// for an item of its category, to requests of at least MinQuantity units, or
+// to those matching both when both are set. Chains are tried by ascending
+// priority and the first match is used.
+type ApprovalChain struct {
+	ID              uint                `gorm:"primaryKey" json:"id"`
+	Name            string              `gorm:"not null" json:"name"`
+	TransactionType string              `json:"transaction_type"`
+	CategoryID      *uint               `json:"category_id"`
+	MinQuantity     *int                `json:"min_quantity"`
+	Priority        int                 `gorm:"not null;default:0" json:"priority"`
+	Active          bool                `gorm:"not null;default:true" json:"active"`
+	Steps           []ApprovalChainStep `gorm:"foreignKey:ChainID;constraint:OnDelete:CASCADE;" json:"steps"`
+	CreatedBy       *uint               `json:"created_by"`
+	CreatedTime     time.Time           `json:"created_time"`
+}
+
+// ApprovalChainStep is one approver role of a chain, decided in ascending
+// position.
+type ApprovalChainStep struct {
+	ID       uint   `gorm:"primaryKey" json:"-"`
+	ChainID  uint   `gorm:"not null;index" json:"-"`
+	Position int    `json:"position"`
+	Role     string `gorm:"not null" json:"role"`
+}
+
+// AdminRole lets an admin decide approval steps of a role. A role with a
+// department only covers requests made by that department.
+type AdminRole struct {
+	ID         uint   `gorm:"primaryKey" json:"-"`
+	AdminID    uint   `gorm:"not null;uniqueIndex:idx_admin_roles_scope" json:"admin_id"`
+	Admin      *Admin `gorm:"foreignKey:AdminID;constraint:OnDelete:CASCADE;" json:"-"`
+	Role       string `gorm:"not null;uniqueIndex:idx_admin_roles_scope" json:"role"`
+	Department string `gorm:"not null;default:'';uniqueIndex:idx_admin_roles_scope" json:"department"`
+}
+
+// TransactionApproval is one step of the approval chain of a loan or
+// inquiry, copied from the chain when the request is created so that later
+// edits to the chain do not affect it.
+type TransactionApproval struct {
+	ID              uint       `gorm:"primaryKey" json:"id"`
+	TransactionType string     `gorm:"index:idx_transaction_approvals_owner" json:"-"`
+	TransactionID   uint       `gorm:"index:idx_transaction_approvals_owner" json:"-"`
+	ChainID         *uint      `json:"chain_id"`
+	Position        int        `json:"position"`
+	Role            string     `json:"role"`
+	Status          string     `json:"status"`
+	AdminID         *uint      `json:"admin_id"`
+	Reason          string     `json:"reason"`
+	Comment         string     `json:"comment"`
+	DecidedTime     *time.Time `json:"decided_time"`
+}
+
+// Create Approval Chain
+type ApprovalChainRequest struct {
+	Name            string   `json:"name"`
+	TransactionType string   `json:"transaction_type"`
+	CategoryID      *uint    `json:"category_id"`
+	MinQuantity     *int     `json:"min_quantity"`
+	Priority        int      `json:"priority"`
+	Active          *bool    `json:"active"`
+	Roles           []string `json:"roles"`
+}
+
+type ApprovalChainResponse struct {
+	Message string        `json:"message"`
+	ID      string        `json:"id"`
+	Chain   ApprovalChain `json:"chain"`
+}
+
+// Delete Approval Chain
+type DeleteApprovalChainResponse struct {
+	Message string `json:"message"`
+	ID      string `json:"id"`
+}
+
+// Set Admin Roles
+type AdminRolesRequest struct {
+	Roles []AdminRoleRequest `json:"roles"`
+}
+
+type AdminRoleRequest struct {
+	Role       string `json:"role"`
+	Department string `json:"department"`
+}
+
+// Get Transaction Approvals
+type TransactionApprovalsResponse struct {
+	ID     string                `json:"id"`
+	Status string                `json:"status"`
+	Steps  []TransactionApproval `json:"steps"`
+}
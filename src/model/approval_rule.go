@@ -0,0 +1,71 @@
+package model
+
+import "time"
+
+// ApprovalRule approves a new loan or inquiry without waiting for an admin
+// when every one of its conditions holds. Unset conditions are ignored, and
+// rules are tried by ascending priority until one matches.
+type ApprovalRule struct {
+	ID                uint      `gorm:"primaryKey" json:"id"`
+	Name              string    `gorm:"not null" json:"name"`
+	TransactionType   string    `json:"transaction_type"`
+	CategoryID        *uint     `json:"category_id"`
+	ItemID            *uint     `json:"item_id"`
+	MaxQuantity       *int      `json:"max_quantity"`
+	Department        string    `json:"department"`
+	MinRemainingStock *int      `json:"min_remaining_stock"`
+	Priority          int       `gorm:"not null;default:0" json:"priority"`
+	Active            bool      `gorm:"not null;default:true" json:"active"`
+	CreatedBy         *uint     `json:"created_by"`
+	CreatedTime       time.Time `json:"created_time"`
+}
+
+// Create Approval Rule
+type ApprovalRuleRequest struct {
+	Name              string `json:"name"`
+	TransactionType   string `json:"transaction_type"`
+	CategoryID        *uint  `json:"category_id"`
+	ItemID            *uint  `json:"item_id"`
+	MaxQuantity       *int   `json:"max_quantity"`
+	Department        string `json:"department"`
+	MinRemainingStock *int   `json:"min_remaining_stock"`
+	Priority          int    `json:"priority"`
+	Active            *bool  `json:"active"`
+}
+
+type ApprovalRuleResponse struct {
+	Message string       `json:"message"`
+	ID      string       `json:"id"`
+	Rule    ApprovalRule `json:"rule"`
+}
+
+// Delete Approval Rule
+type DeleteApprovalRuleResponse struct {
+	Message string `json:"message"`
+	ID      string `json:"id"`
+}
+
+// Dry Run Approval Rules
+type ApprovalRuleDryRunRequest struct {
+	TransactionType    string            `json:"transaction_type"`
+	EmployeeID         *uint             `json:"employee_id"`
+	EmployeeDepartment string            `json:"employee_department"`
+	ItemID             *uint             `json:"item_id"`
+	Quantity           int               `json:"quantity"`
+	Lines              []TransactionLine `json:"lines"`
+}
+
+type ApprovalRuleDryRunResponse struct {
+	Matched    bool                   `json:"matched"`
+	Rule       *ApprovalRule          `json:"rule"`
+	Evaluation []ApprovalRuleDecision `json:"evaluation"`
+}
+
+// ApprovalRuleDecision tells whether one rule matched a request and, when it
+// did not, which of its conditions failed.
+type ApprovalRuleDecision struct {
+	RuleID   uint     `json:"rule_id"`
+	Name     string   `json:"name"`
+	Matched  bool     `json:"matched"`
+	Failures []string `json:"failures,omitempty"`
+}
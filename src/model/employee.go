@@ -0,0 +1,70 @@
+package model
+
+import "time"
+
+// Employee is an entry of the employee directory. Transactions reference it
+// so that the same person is counted once however their name was typed.
+// Employees imported from existing transactions have no ID number until an
+// admin fills it in.
+type Employee struct {
+	ID             uint      `gorm:"primaryKey" json:"id"`
+	IDNumber       *string   `gorm:"uniqueIndex" json:"id_number"`
+	Name           string    `gorm:"not null" json:"name"`
+	NormalizedName string    `gorm:"not null;index" json:"-"`
+	Department     string    `gorm:"index" json:"department"`
+	Position       string    `json:"position"`
+	CreatedTime    time.Time `json:"created_time"`
+}
+
+// Create Employee
+type EmployeeRequest struct {
+	IDNumber   string `json:"id_number"`
+	Name       string `json:"name"`
+	Department string `json:"department"`
+	Position   string `json:"position"`
+}
+
+type EmployeeResponse struct {
+	Message  string   `json:"message"`
+	ID       string   `json:"id"`
+	Employee Employee `json:"employee"`
+}
+
+// Get All Employees
+type GetEmployeesResponse struct {
+	Employees []Employee `json:"employees"`
+	Total     int64      `json:"total"`
+	Page      int        `json:"page"`
+	Limit     int        `json:"limit"`
+}
+
+// EmployeeSuggestion is an autocomplete match for the public request forms,
+// without the employee's ID number.
+type EmployeeSuggestion struct {
+	ID         uint   `json:"id"`
+	Name       string `json:"name"`
+	Department string `json:"department"`
+	Position   string `json:"position"`
+}
+
+// Delete Employee
+type DeleteEmployeeResponse struct {
+	Message string `json:"message"`
+	ID      string `json:"id"`
+}
+
+// Get Employee Activity
+type EmployeeActivityResponse struct {
+	Employee    Employee              `json:"employee"`
+	OpenLoans   []LoanTransaction     `json:"open_loans"`
+	Inquiries   []InquiryTransaction  `json:"inquiries"`
+	Consumption []EmployeeConsumption `json:"consumption"`
+}
+
+// EmployeeConsumption is how much of an item an employee took through
+// completed inquiries.
+type EmployeeConsumption struct {
+	ItemID   uint   `json:"item_id"`
+	ItemName string `json:"item_name"`
+	Quantity int    `json:"quantity"`
+}
@@ -0,0 +1,21 @@
+package model
+
+import "time"
+
+// IdempotencyKey remembers the response of a request sent with an
+// Idempotency-Key header, so a retry of the same request replays it instead
+// of running again. Keys are scoped to the client that sent them. StatusCode
+// stays 0 while the first request is running. Secrets holds the encrypted
+// fields taken out of Response.
+type IdempotencyKey struct {
+	ID          uint   `gorm:"primaryKey"`
+	Key         string `gorm:"not null;uniqueIndex:idx_idempotency_keys_client_scope"`
+	Endpoint    string `gorm:"not null;uniqueIndex:idx_idempotency_keys_client_scope"`
+	Client      string `gorm:"not null;default:'';uniqueIndex:idx_idempotency_keys_client_scope"`
+	RequestHash string `gorm:"not null"`
+	StatusCode  int    `gorm:"not null;default:0"`
+	ContentType string
+	Response    []byte
+	Secrets     []byte
+	CreatedTime time.Time `gorm:"index"`
+}
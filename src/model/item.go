@@ -1,9 +1,17 @@
 package model
 
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
 type Item struct {
 	ID         uint     `gorm:"primaryKey" json:"id"`
 	Name       string   `json:"name"`
-	Quantity   int      `json:"quantity"`
+	Quantity   int      `gorm:"check:chk_items_quantity_non_negative,quantity >= 0" json:"quantity"`
+	Reserved   int      `gorm:"not null;default:0;check:chk_items_reserved_within_quantity,reserved >= 0 AND reserved <= quantity" json:"reserved"`
+	Available  int      `gorm:"-" json:"available"`
 	Shelf      string   `json:"shelf"`
 	CategoryID uint     `json:"category_id"`
 	Category   Category `gorm:"foreignKey:CategoryID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;" json:"-"`
@@ -13,6 +21,32 @@ type Item struct {
 	InsertionTransactions []InsertionTransaction `gorm:"constraint:OnDelete:SET NULL;" json:"-"`
 }
 
+// AfterFind derives the quantity that is on hand and not yet promised to an
+// approved transaction.
+func (item *Item) AfterFind(tx *gorm.DB) error {
+	item.Available = item.Quantity - item.Reserved
+	return nil
+}
+
+// ItemMovement is one entry of the append-only stock ledger. Every change to
+// an item's quantity writes one, so the balance can be traced back in time.
+type ItemMovement struct {
+	ID              uint      `gorm:"primaryKey" json:"id"`
+	ItemID          uint      `gorm:"not null;index:idx_item_movements_item_time" json:"item_id"`
+	Delta           int       `gorm:"not null" json:"delta"`
+	Balance         int       `gorm:"not null" json:"balance"`
+	Reason          string    `gorm:"not null" json:"reason"`
+	TransactionUUID string    `json:"transaction_uuid,omitempty"`
+	ActorID         *uint     `json:"actor_id,omitempty"`
+	Time            time.Time `gorm:"not null;index:idx_item_movements_item_time" json:"time"`
+}
+
+type ItemBalanceResponse struct {
+	ItemID  uint      `json:"item_id"`
+	Time    time.Time `json:"at"`
+	Balance int       `json:"balance"`
+}
+
 // Delete Item
 type DeleteItemResponse struct {
 	Message string `json:"message"`
@@ -0,0 +1,93 @@
+package model
+
+import "time"
+
+// ConsumptionQuota caps how much of an item or category an employee or a
+// department may take through inquiries over a rolling period. Department
+// quotas apply to the department as a whole, or to each of its employees
+// when PerEmployee is set.
+type ConsumptionQuota struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	EmployeeID  *uint     `gorm:"index" json:"employee_id"`
+	Employee    *Employee `gorm:"foreignKey:EmployeeID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+	Department  string    `gorm:"index" json:"department"`
+	PerEmployee bool      `gorm:"not null;default:false" json:"per_employee"`
+	ItemID      *uint     `gorm:"index" json:"item_id"`
+	Item        *Item     `gorm:"foreignKey:ItemID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+	CategoryID  *uint     `gorm:"index" json:"category_id"`
+	Category    *Category `gorm:"foreignKey:CategoryID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+	Quantity    int       `gorm:"not null" json:"quantity"`
+	PeriodDays  int       `gorm:"not null" json:"period_days"`
+	Enforcement string    `gorm:"not null" json:"enforcement"`
+	Notes       string    `json:"notes"`
+	CreatedBy   *uint     `json:"created_by"`
+	CreatedTime time.Time `json:"created_time"`
+}
+
+// QuotaViolation records an inquiry that went over a flagging quota when it
+// was made.
+type QuotaViolation struct {
+	ID                   uint              `gorm:"primaryKey" json:"id"`
+	QuotaID              uint              `gorm:"not null;index" json:"quota_id"`
+	Quota                *ConsumptionQuota `gorm:"foreignKey:QuotaID;constraint:OnDelete:CASCADE;" json:"-"`
+	InquiryTransactionID uint              `gorm:"not null;index" json:"inquiry_transaction_id"`
+	Used                 int               `json:"used"`
+	Requested            int               `json:"requested"`
+	Limit                int               `json:"limit"`
+	Time                 time.Time         `json:"time"`
+}
+
+// Create Quota
+type QuotaRequest struct {
+	EmployeeID  *uint  `json:"employee_id"`
+	Department  string `json:"department"`
+	PerEmployee bool   `json:"per_employee"`
+	ItemID      *uint  `json:"item_id"`
+	CategoryID  *uint  `json:"category_id"`
+	Quantity    int    `json:"quantity"`
+	PeriodDays  int    `json:"period_days"`
+	Enforcement string `json:"enforcement"`
+	Notes       string `json:"notes"`
+}
+
+type QuotaResponse struct {
+	Message string           `json:"message"`
+	ID      string           `json:"id"`
+	Quota   ConsumptionQuota `json:"quota"`
+}
+
+// QuotaUsage is how much of a quota has been used in its current period.
+// For per-employee quotas Used is that of the heaviest user, and Employees
+// breaks it down per person.
+type QuotaUsage struct {
+	Quota       ConsumptionQuota     `json:"quota"`
+	PeriodStart time.Time            `json:"period_start"`
+	Used        int                  `json:"used"`
+	Remaining   int                  `json:"remaining"`
+	Exceeded    bool                 `json:"exceeded"`
+	Employees   []QuotaEmployeeUsage `json:"employees,omitempty"`
+}
+
+type QuotaEmployeeUsage struct {
+	EmployeeID   *uint  `json:"employee_id"`
+	EmployeeName string `json:"employee_name"`
+	Used         int    `json:"used"`
+	Remaining    int    `json:"remaining"`
+	Exceeded     bool   `json:"exceeded"`
+}
+
+// QuotaWarning tells the requester which quota a new inquiry goes over.
+type QuotaWarning struct {
+	QuotaID     uint   `json:"quota_id"`
+	Enforcement string `json:"enforcement"`
+	Limit       int    `json:"limit"`
+	Used        int    `json:"used"`
+	Requested   int    `json:"requested"`
+	PeriodDays  int    `json:"period_days"`
+}
+
+// Delete Quota
+type DeleteQuotaResponse struct {
+	Message string `json:"message"`
+	ID      string `json:"id"`
+}
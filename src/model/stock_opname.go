@@ -0,0 +1,75 @@
+package model
+
+import "time"
+
+// StockOpname is a physical count of every item in one storage. While it is
+// open, counters record what they find; committing it adjusts the stock to the
+// counted quantities and freezes the variance report.
+type StockOpname struct {
+	ID         uint                    `gorm:"primaryKey" json:"id"`
+	StorageID  int                     `gorm:"not null;index" json:"storage_id"`
+	Storage    *Storage                `gorm:"foreignKey:StorageID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"storage,omitempty"`
+	Status     string                  `gorm:"not null" json:"status"`
+	Notes      string                  `json:"notes"`
+	OpenedBy   *uint                   `json:"opened_by"`
+	OpenedTime time.Time               `json:"opened_time"`
+	ClosedBy   *uint                   `json:"closed_by"`
+	ClosedTime *time.Time              `json:"closed_time"`
+	Counts     []StockOpnameCount      `gorm:"foreignKey:StockOpnameID;constraint:OnDelete:CASCADE;" json:"counts,omitempty"`
+	Report     []StockOpnameReportLine `gorm:"foreignKey:StockOpnameID;constraint:OnDelete:CASCADE;" json:"report,omitempty"`
+}
+
+// StockOpnameCount is the quantity of an item found by one counter. Several
+// counters sharing a shelf each record their part, and the counted quantity
+// of the item is their sum.
+type StockOpnameCount struct {
+	ID            uint      `gorm:"primaryKey" json:"id"`
+	StockOpnameID uint      `gorm:"not null;uniqueIndex:idx_stock_opname_counts_counter" json:"-"`
+	ItemID        uint      `gorm:"not null;uniqueIndex:idx_stock_opname_counts_counter" json:"item_id"`
+	Counter       string    `gorm:"not null;uniqueIndex:idx_stock_opname_counts_counter" json:"counter"`
+	Quantity      int       `gorm:"not null" json:"quantity"`
+	AdminID       *uint     `json:"admin_id,omitempty"`
+	Time          time.Time `json:"time"`
+}
+
+// StockOpnameReportLine is one row of the report frozen when a count is
+// committed.
+type StockOpnameReportLine struct {
+	ID              uint   `gorm:"primaryKey" json:"-"`
+	StockOpnameID   uint   `gorm:"not null;index" json:"-"`
+	ItemID          uint   `json:"item_id"`
+	ItemName        string `json:"item_name"`
+	Shelf           string `json:"shelf"`
+	SystemQuantity  int    `json:"system_quantity"`
+	CountedQuantity *int   `json:"counted_quantity"`
+	Variance        int    `json:"variance"`
+}
+
+// Open Stock Opname
+type OpenStockOpnameRequest struct {
+	Notes string `json:"notes"`
+}
+
+// Record Stock Opname Counts
+type RecordStockOpnameCountsRequest struct {
+	Counter string                    `json:"counter"`
+	Counts  []StockOpnameCountRequest `json:"counts"`
+}
+
+type StockOpnameCountRequest struct {
+	ItemID   uint `json:"item_id"`
+	Quantity int  `json:"quantity"`
+}
+
+type StockOpnameResponse struct {
+	Message string      `json:"message"`
+	ID      string      `json:"id"`
+	Opname  StockOpname `json:"stock_opname"`
+}
+
+// Stock Opname Variance
+type StockOpnameVarianceResponse struct {
+	ID     uint                    `json:"id"`
+	Status string                  `json:"status"`
+	Lines  []StockOpnameReportLine `json:"lines"`
+}
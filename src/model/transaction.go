@@ -5,42 +5,144 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 // Transaction models
 type LoanTransaction struct {
-	ID                 uint       `gorm:"primaryKey" json:"id"`
-	UUID               uuid.UUID  `gorm:"type:uuid;default:uuid_generate_v4();uniqueIndex" json:"uuid"`
-	TransactionType    string     `json:"transaction_type"`
-	EmployeeName       string     `json:"employee_name"`
-	EmployeeDepartment string     `json:"employee_department"`
-	EmployeePosition   string     `json:"employee_position"`
-	Quantity           int        `json:"quantity"`
-	Status             string     `json:"status"`
-	Time               time.Time  `json:"time"`
-	Notes              string     `json:"notes"`
-	ItemID             uint       `json:"item_id"`
-	Item               *Item      `gorm:"foreignKey:ItemID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;" json:"item"`
-	LoanTime           time.Time  `json:"loan_time"`
-	ReturnTime         time.Time  `json:"return_time"`
-	CompletedTime      *time.Time `json:"completed_time"`
-	ReturnedTime       *time.Time `json:"returned_time"`
+	ID                  uint              `gorm:"primaryKey" json:"id"`
+	UUID                uuid.UUID         `gorm:"type:uuid;default:uuid_generate_v4();uniqueIndex" json:"uuid"`
+	TransactionType     string            `json:"transaction_type"`
+	EmployeeName        string            `json:"employee_name"`
+	EmployeeDepartment  string            `json:"employee_department"`
+	EmployeePosition    string            `json:"employee_position"`
+	EmployeeID          *uint             `gorm:"index" json:"employee_id"`
+	Employee            *Employee         `gorm:"foreignKey:EmployeeID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;" json:"employee,omitempty"`
+	Quantity            int               `json:"quantity"`
+	Status              string            `json:"status"`
+	Time                time.Time         `json:"time"`
+	Notes               string            `json:"notes"`
+	ItemID              *uint             `json:"item_id"`
+	Item                *Item             `gorm:"foreignKey:ItemID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;" json:"item"`
+	Lines               []TransactionLine `gorm:"polymorphic:Transaction;polymorphicValue:loan" json:"lines"`
+	LoanTime            time.Time         `json:"loan_time"`
+	ReturnTime          time.Time         `json:"return_time"`
+	CompletedTime       *time.Time        `json:"completed_time"`
+	StatusReason        string            `json:"status_reason"`
+	AdminNote           string            `json:"admin_note"`
+	TrackingTokenHash   string            `gorm:"index" json:"-"`
+	AutoApprovalRuleID  *uint             `json:"auto_approval_rule_id"`
+	DeletedAt           gorm.DeletedAt    `gorm:"index" json:"-"`
+	DeletedBy           *uint             `json:"-"`
+	DeleteReason        string            `json:"-"`
+	ReturnedTime        *time.Time        `json:"returned_time"`
+	OverdueTime         *time.Time        `json:"overdue_time"`
+	ReturnedQuantity    int               `gorm:"not null;default:0" json:"returned_quantity"`
+	OutstandingQuantity int               `gorm:"-" json:"outstanding_quantity"`
+	Returns             []LoanReturn      `gorm:"foreignKey:LoanTransactionID;constraint:OnDelete:CASCADE;" json:"returns,omitempty"`
+	Extensions          []LoanExtension   `gorm:"foreignKey:LoanTransactionID;constraint:OnDelete:CASCADE;" json:"extensions,omitempty"`
+}
+
+// AfterFind derives how many loaned units have not been brought back yet.
+// When the lines are loaded only the units actually handed out are counted.
+func (loan *LoanTransaction) AfterFind(tx *gorm.DB) error {
+	if len(loan.Lines) == 0 {
+		loan.OutstandingQuantity = loan.Quantity - loan.ReturnedQuantity
+		return nil
+	}
+
+	loan.OutstandingQuantity = 0
+	for _, line := range loan.Lines {
+		if line.Status == "completed" {
+			loan.OutstandingQuantity += line.Quantity - line.ReturnedQuantity
+		}
+	}
+	return nil
+}
+
+// TransactionLine is one requested item of a loan or inquiry. Each line moves
+// through its own status so admins can decide on items individually.
+type TransactionLine struct {
+	ID               uint       `gorm:"primaryKey" json:"id"`
+	TransactionType  string     `gorm:"index:idx_transaction_lines_owner" json:"-"`
+	TransactionID    uint       `gorm:"index:idx_transaction_lines_owner" json:"-"`
+	ItemID           uint       `json:"item_id"`
+	Item             *Item      `gorm:"foreignKey:ItemID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;" json:"item,omitempty"`
+	Quantity         int        `json:"quantity"`
+	ReturnedQuantity int        `gorm:"not null;default:0" json:"returned_quantity"`
+	Status           string     `json:"status"`
+	CompletedTime    *time.Time `json:"completed_time"`
+}
+
+// LoanReturn records one hand-back of some or all of a loan line's units.
+// Quantity counts every unit settled, whether it came back good, damaged or
+// not at all.
+type LoanReturn struct {
+	ID                uint      `gorm:"primaryKey" json:"id"`
+	LoanTransactionID uint      `json:"loan_transaction_id"`
+	TransactionLineID *uint     `json:"line_id"`
+	Quantity          int       `json:"quantity"`
+	Good              int       `gorm:"not null;default:0" json:"good"`
+	Damaged           int       `gorm:"not null;default:0" json:"damaged"`
+	Lost              int       `gorm:"not null;default:0" json:"lost"`
+	Time              time.Time `json:"time"`
+}
+
+// LoanExtension is a request to move a loan's return time further out.
+type LoanExtension struct {
+	ID                  uint       `gorm:"primaryKey" json:"id"`
+	LoanTransactionID   uint       `json:"loan_transaction_id"`
+	PreviousReturnTime  time.Time  `json:"previous_return_time"`
+	RequestedReturnTime time.Time  `json:"requested_return_time"`
+	Reason              string     `json:"reason"`
+	Status              string     `json:"status"`
+	RequestedTime       time.Time  `json:"requested_time"`
+	DecidedBy           *uint      `json:"decided_by"`
+	DecidedTime         *time.Time `json:"decided_time"`
+}
+
+// TransactionEvent records one status change of a transaction, or of one of
+// its lines when LineID is set. Moving a transaction to the trash, restoring
+// and purging it are recorded with Action set and the status on both sides.
+type TransactionEvent struct {
+	ID              uint      `gorm:"primaryKey" json:"id"`
+	TransactionType string    `gorm:"index:idx_transaction_events_owner" json:"transaction_type"`
+	TransactionUUID uuid.UUID `gorm:"type:uuid;index:idx_transaction_events_owner" json:"transaction_uuid"`
+	LineID          *uint     `json:"line_id,omitempty"`
+	FromStatus      string    `json:"from_status"`
+	ToStatus        string    `json:"to_status"`
+	Action          string    `gorm:"not null;default:''" json:"action,omitempty"`
+	AdminID         *uint     `json:"admin_id"`
+	Reason          string    `json:"reason"`
+	Comment         string    `json:"comment"`
+	Time            time.Time `json:"time"`
 }
 
 type InquiryTransaction struct {
-	ID                 uint       `gorm:"primaryKey" json:"id"`
-	UUID               uuid.UUID  `gorm:"type:uuid;default:uuid_generate_v4();uniqueIndex" json:"uuid"`
-	TransactionType    string     `json:"transaction_type"`
-	EmployeeName       string     `json:"employee_name"`
-	EmployeeDepartment string     `json:"employee_department"`
-	EmployeePosition   string     `json:"employee_position"`
-	Quantity           int        `json:"quantity"`
-	Status             string     `json:"status"`
-	Notes              string     `json:"notes"`
-	Time               time.Time  `json:"time"`
-	ItemID             uint       `json:"item_id"`
-	Item               *Item      `gorm:"foreignKey:ItemID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;" json:"item"`
-	CompletedTime      *time.Time `json:"completed_time"`
+	ID                 uint              `gorm:"primaryKey" json:"id"`
+	UUID               uuid.UUID         `gorm:"type:uuid;default:uuid_generate_v4();uniqueIndex" json:"uuid"`
+	TransactionType    string            `json:"transaction_type"`
+	EmployeeName       string            `json:"employee_name"`
+	EmployeeDepartment string            `json:"employee_department"`
+	EmployeePosition   string            `json:"employee_position"`
+	EmployeeID         *uint             `gorm:"index" json:"employee_id"`
+	Employee           *Employee         `gorm:"foreignKey:EmployeeID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;" json:"employee,omitempty"`
+	Quantity           int               `json:"quantity"`
+	Status             string            `json:"status"`
+	Notes              string            `json:"notes"`
+	Time               time.Time         `json:"time"`
+	ItemID             *uint             `json:"item_id"`
+	Item               *Item             `gorm:"foreignKey:ItemID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;" json:"item"`
+	Lines              []TransactionLine `gorm:"polymorphic:Transaction;polymorphicValue:inquiry" json:"lines"`
+	CompletedTime      *time.Time        `json:"completed_time"`
+	QuotaExceeded      bool              `gorm:"not null;default:false" json:"quota_exceeded"`
+	AutoApprovalRuleID *uint             `json:"auto_approval_rule_id"`
+	StatusReason       string            `json:"status_reason"`
+	AdminNote          string            `json:"admin_note"`
+	TrackingTokenHash  string            `gorm:"index" json:"-"`
+	DeletedAt          gorm.DeletedAt    `gorm:"index" json:"-"`
+	DeletedBy          *uint             `json:"-"`
+	DeleteReason       string            `json:"-"`
 }
 
 type InsertionTransaction struct {
@@ -50,6 +152,8 @@ type InsertionTransaction struct {
 	EmployeeName       string         `json:"employee_name"`
 	EmployeeDepartment string         `json:"employee_department"`
 	EmployeePosition   string         `json:"employee_position"`
+	EmployeeID         *uint          `gorm:"index" json:"employee_id"`
+	Employee           *Employee      `gorm:"foreignKey:EmployeeID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;" json:"employee,omitempty"`
 	Status             string         `json:"status"`
 	Notes              string         `json:"notes"`
 	Time               time.Time      `json:"time"`
@@ -58,26 +162,41 @@ type InsertionTransaction struct {
 	Item               *Item          `gorm:"foreignKey:ItemID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;" json:"item"`
 	ItemRequest        ItemRequestDTO `gorm:"embedded;embeddedPrefix:item_request_" json:"item_request"`
 	CompletedTime      *time.Time     `json:"completed_time"`
+	StatusReason       string         `json:"status_reason"`
+	AdminNote          string         `json:"admin_note"`
+	TrackingTokenHash  string         `gorm:"index" json:"-"`
+	DeletedAt          gorm.DeletedAt `gorm:"index" json:"-"`
+	DeletedBy          *uint          `json:"-"`
+	DeleteReason       string         `json:"-"`
 }
 
 // Create Loan Transaction
 type CreateLoanTransactionResponse struct {
-	Message      string    `json:"message"`
-	ID           string    `json:"id"`
-	EmployeeName string    `json:"employee_name"`
-	Item         *Item     `json:"item"`
-	Quantity     int       `json:"quantity"`
-	LoanTime     time.Time `json:"loan_time"`
-	ReturnTime   time.Time `json:"return_time"`
+	Message            string            `json:"message"`
+	ID                 string            `json:"id"`
+	TrackingToken      string            `json:"tracking_token"`
+	Status             string            `json:"status"`
+	AutoApprovalRuleID *uint             `json:"auto_approval_rule_id,omitempty"`
+	EmployeeName       string            `json:"employee_name"`
+	Item               *Item             `json:"item"`
+	Quantity           int               `json:"quantity"`
+	Lines              []TransactionLine `json:"lines"`
+	LoanTime           time.Time         `json:"loan_time"`
+	ReturnTime         time.Time         `json:"return_time"`
 }
 
 // Create Inquiry Transaction
 type CreateInquiryTransactionResponse struct {
-	Message      string `json:"message"`
-	ID           string `json:"id"`
-	EmployeeName string `json:"employee_name"`
-	Item         *Item  `json:"item"`
-	Quantity     int    `json:"quantity"`
+	Message            string            `json:"message"`
+	ID                 string            `json:"id"`
+	TrackingToken      string            `json:"tracking_token"`
+	Status             string            `json:"status"`
+	AutoApprovalRuleID *uint             `json:"auto_approval_rule_id,omitempty"`
+	EmployeeName       string            `json:"employee_name"`
+	Item               *Item             `json:"item"`
+	Quantity           int               `json:"quantity"`
+	Lines              []TransactionLine `json:"lines"`
+	QuotaWarnings      []QuotaWarning    `json:"quota_warnings,omitempty"`
 }
 
 // Create Insertion Transaction
@@ -85,6 +204,7 @@ type CreateInsertionTransactionDTO struct {
 	EmployeeName       string         `json:"employee_name" validate:"required"`
 	EmployeeDepartment string         `json:"employee_department" validate:"required"`
 	EmployeePosition   string         `json:"employee_position" validate:"required"`
+	EmployeeID         *uint          `json:"employee_id"`
 	Notes              string         `json:"notes"`
 	Image              []byte         `json:"image" validate:"required"`
 	ItemRequest        ItemRequestDTO `json:"item_request" validate:"required"`
@@ -110,44 +230,277 @@ type InsertionTransactionRequest struct {
 }
 
 type CreateInsertionTransactionResponse struct {
-	Message      string `json:"message"`
-	ID           string `json:"id"`
-	EmployeeName string `json:"employee_name"`
-	ItemName     string `json:"item_name"`
-	Quantity     int    `json:"quantity"`
+	Message       string `json:"message"`
+	ID            string `json:"id"`
+	TrackingToken string `json:"tracking_token"`
+	EmployeeName  string `json:"employee_name"`
+	ItemName      string `json:"item_name"`
+	Quantity      int    `json:"quantity"`
 }
 
 // Get All Transactions
 type GetAllTransactionsResponse struct {
-	UUID               string          `json:"uuid"`
-	TransactionType    string          `json:"transaction_type"`
-	EmployeeName       string          `json:"employee_name"`
-	EmployeeDepartment string          `json:"employee_department"`
-	EmployeePosition   string          `json:"employee_position"`
-	Quantity           int             `json:"quantity"`
-	Status             string          `json:"status"`
-	Notes              string          `json:"notes"`
-	Time               time.Time       `json:"time"`
-	Image              *[]byte         `json:"image"`
-	LoanTime           *time.Time      `json:"loan_time,omitempty"`
-	ReturnTime         *time.Time      `json:"return_time,omitempty"`
-	ItemRequest        *ItemRequestDTO `json:"item_request"`
-	CompletedTime      *time.Time      `json:"completed_time"`
-	ReturnedTime       *time.Time      `json:"returned_time"`
+	UUID                string            `json:"uuid"`
+	TransactionType     string            `json:"transaction_type"`
+	EmployeeName        string            `json:"employee_name"`
+	EmployeeDepartment  string            `json:"employee_department"`
+	EmployeePosition    string            `json:"employee_position"`
+	EmployeeID          *uint             `json:"employee_id"`
+	Quantity            int               `json:"quantity"`
+	Status              string            `json:"status"`
+	Notes               string            `json:"notes"`
+	Time                time.Time         `json:"time"`
+	Image               *[]byte           `json:"image"`
+	LoanTime            *time.Time        `json:"loan_time,omitempty"`
+	ReturnTime          *time.Time        `json:"return_time,omitempty"`
+	ItemRequest         *ItemRequestDTO   `json:"item_request"`
+	CompletedTime       *time.Time        `json:"completed_time"`
+	StatusReason        string            `json:"status_reason"`
+	AdminNote           string            `json:"admin_note"`
+	QuotaExceeded       bool              `json:"quota_exceeded,omitempty"`
+	AutoApprovalRuleID  *uint             `json:"auto_approval_rule_id,omitempty"`
+	ReturnedTime        *time.Time        `json:"returned_time"`
+	DeletedTime         *time.Time        `json:"deleted_time,omitempty"`
+	DeletedBy           *uint             `json:"deleted_by,omitempty"`
+	DeleteReason        string            `json:"delete_reason,omitempty"`
+	OverdueTime         *time.Time        `json:"overdue_time,omitempty"`
+	OutstandingQuantity *int              `json:"outstanding_quantity,omitempty"`
+	Extensions          []LoanExtension   `json:"extensions,omitempty"`
+	Lines               []TransactionLine `json:"lines,omitempty"`
+	Transfer            *TransferDetails  `json:"transfer,omitempty"`
+}
+
+// TransactionFilter narrows down and orders the transaction listing. Empty
+// fields do not filter.
+type TransactionFilter struct {
+	Type         string
+	Statuses     []string
+	EmployeeName string
+	EmployeeID   *uint
+	Department   string
+	ItemID       *uint
+	CategoryID   *uint
+	StorageID    *uint
+	From         *time.Time
+	To           *time.Time
+	Search       string
+	Sort         string
+	Order        string
+	// Deleted lists the transactions in the trash instead of the others.
+	Deleted bool
+}
+
+// TransactionFeedEntry is one row of the transaction_feed view, which lists
+// every loan, inquiry, insertion and transfer in a single table.
+type TransactionFeedEntry struct {
+	TransactionType string
+	ID              uint
+	UUID            uuid.UUID
+	EmployeeName    string
+	Status          string
+	Time            time.Time
+}
+
+func (TransactionFeedEntry) TableName() string {
+	return "transaction_feed"
+}
+
+// TransactionCursor points at the last entry of a page of the transaction
+// listing. Value holds the sort column when sorting by status or employee.
+// Sort and Order are those of the listing the cursor was issued for.
+type TransactionCursor struct {
+	Type  string    `json:"t"`
+	ID    uint      `json:"i"`
+	Time  time.Time `json:"tm"`
+	Value string    `json:"v,omitempty"`
+	Sort  string    `json:"s"`
+	Order string    `json:"o"`
+}
+
+type GetTransactionsResponse struct {
+	Transactions []GetAllTransactionsResponse `json:"transactions"`
+	Total        int64                        `json:"total"`
+	Page         int                          `json:"page,omitempty"`
+	Limit        int                          `json:"limit"`
+	NextCursor   string                       `json:"next_cursor,omitempty"`
+}
+
+// Get Overdue Loans
+type OverdueLoanResponse struct {
+	UUID                string     `json:"uuid"`
+	EmployeeName        string     `json:"employee_name"`
+	EmployeeDepartment  string     `json:"employee_department"`
+	EmployeePosition    string     `json:"employee_position"`
+	Item                *Item      `json:"item"`
+	Quantity            int        `json:"quantity"`
+	OutstandingQuantity int        `json:"outstanding_quantity"`
+	LoanTime            time.Time  `json:"loan_time"`
+	ReturnTime          time.Time  `json:"return_time"`
+	OverdueTime         *time.Time `json:"overdue_time"`
+	DaysLate            int        `json:"days_late"`
+}
+
+// Return Loan Transaction
+// ReturnLoanRequest settles quantity units of a loan line, of which damaged
+// came back damaged and lost did not come back. The rest came back good.
+type ReturnLoanRequest struct {
+	LineID   uint `json:"line_id"`
+	Quantity int  `json:"quantity"`
+	Damaged  int  `json:"damaged"`
+	Lost     int  `json:"lost"`
+}
+
+// ReturnCondition tells how many of the outstanding units of a loan line came
+// back damaged or were lost when the whole loan is returned at once. LineID
+// may be omitted when only one line is out.
+type ReturnCondition struct {
+	LineID  uint `json:"line_id"`
+	Damaged int  `json:"damaged"`
+	Lost    int  `json:"lost"`
+}
+
+type ReturnLoanResponse struct {
+	Message             string       `json:"message"`
+	ID                  string       `json:"id"`
+	Status              string       `json:"status"`
+	ReturnedQuantity    int          `json:"returned_quantity"`
+	OutstandingQuantity int          `json:"outstanding_quantity"`
+	Returns             []LoanReturn `json:"returns"`
+}
+
+// Loan Extension
+type CreateLoanExtensionRequest struct {
+	ReturnTime time.Time `json:"return_time"`
+	Reason     string    `json:"reason"`
+}
+
+type LoanExtensionResponse struct {
+	Message   string        `json:"message"`
+	ID        string        `json:"id"`
+	Extension LoanExtension `json:"extension"`
 }
 
 // Update Transaction
+// UpdateTransactionStatusRequest is the optional body of a status change. The
+// reason is shown to the requester and is required when rejecting a request
+// or sending it back as incomplete. ItemID and CreateNewItem tell how a
+// completed insertion is stocked: into an existing item, or as a new one.
+type UpdateTransactionStatusRequest struct {
+	Reason        string `json:"reason"`
+	AdminNote     string `json:"admin_note"`
+	Comment       string `json:"comment"`
+	ItemID        *uint  `json:"item_id,omitempty"`
+	CreateNewItem bool   `json:"create_new_item,omitempty"`
+	// Conditions applies when a loan is returned. Units not listed came back
+	// good.
+	Conditions []ReturnCondition `json:"conditions,omitempty"`
+}
+
+// Track Transaction
+type TrackTransactionResponse struct {
+	ID              string            `json:"id"`
+	TransactionType string            `json:"transaction_type"`
+	Status          string            `json:"status"`
+	StatusReason    string            `json:"status_reason"`
+	Time            time.Time         `json:"time"`
+	ReturnTime      *time.Time        `json:"return_time,omitempty"`
+	CompletedTime   *time.Time        `json:"completed_time"`
+	Lines           []TransactionLine `json:"lines,omitempty"`
+	Cancellable     bool              `json:"cancellable"`
+	Timeline        []TrackingEvent   `json:"timeline"`
+}
+
+// Bulk Update Transaction Status
+type BulkUpdateTransactionStatusRequest struct {
+	IDs       []string `json:"ids"`
+	Status    string   `json:"status"`
+	Atomic    bool     `json:"atomic"`
+	Reason    string   `json:"reason"`
+	AdminNote string   `json:"admin_note"`
+	Comment   string   `json:"comment"`
+}
+
+type BulkUpdateTransactionStatusResponse struct {
+	Status    string                              `json:"status"`
+	Atomic    bool                                `json:"atomic"`
+	Succeeded int                                 `json:"succeeded"`
+	Failed    int                                 `json:"failed"`
+	Results   []BulkUpdateTransactionStatusResult `json:"results"`
+}
+
+// BulkUpdateTransactionStatusResult is the outcome of one transaction of a
+// bulk status update.
+type BulkUpdateTransactionStatusResult struct {
+	ID      string `json:"id"`
+	Success bool   `json:"success"`
+	Message string `json:"message,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// Cancel Transaction
+type CancelTransactionRequest struct {
+	Reason string `json:"reason"`
+}
+
+// TrackingEvent is a status change as shown to the requester, without the
+// admin details of TransactionEvent.
+type TrackingEvent struct {
+	LineID     *uint     `json:"line_id,omitempty"`
+	FromStatus string    `json:"from_status"`
+	ToStatus   string    `json:"to_status"`
+	Reason     string    `json:"reason,omitempty"`
+	Time       time.Time `json:"time"`
+}
+
+// Insertion Item Matches
+type InsertionItemMatchResponse struct {
+	ID          string `json:"id"`
+	Match       *Item  `json:"match"`
+	Suggestions []Item `json:"suggestions"`
+}
+
 type UpdateTransactionResponse struct {
 	Message string `json:"message"`
 	ID      string `json:"id"`
 }
 
+// Get Transaction Transitions
+type TransactionTransitionsResponse struct {
+	ID          string                    `json:"id"`
+	Status      string                    `json:"status"`
+	Transitions []string                  `json:"transitions"`
+	Lines       []LineTransitionsResponse `json:"lines,omitempty"`
+}
+
+type LineTransitionsResponse struct {
+	ID          uint     `json:"id"`
+	Status      string   `json:"status"`
+	Transitions []string `json:"transitions"`
+}
+
+// Get Transaction History
+type TransactionHistoryResponse struct {
+	ID     string             `json:"id"`
+	Events []TransactionEvent `json:"events"`
+}
+
 // Delete Transaction
+type DeleteTransactionRequest struct {
+	Reason string `json:"reason"`
+}
+
 type DeleteTransactionResponse struct {
 	Message string `json:"message"`
 	ID      string `json:"id"`
 }
 
+// Restore Transaction
+type RestoreTransactionResponse struct {
+	Message string `json:"message"`
+	ID      string `json:"id"`
+	Status  string `json:"status"`
+}
+
 type ExportTransaction struct {
 	TransactionType    string
 	ID                 int
@@ -166,5 +519,12 @@ type ExportTransaction struct {
 	ReturnTime         sql.NullTime
 	CompletedTime      sql.NullTime
 	ReturnedTime       sql.NullTime
+	StatusReason       sql.NullString
+	AdminNote          sql.NullString
 	Image              sql.NullString
+	// Where a transfer moves its source item to. Empty for other types.
+	DestinationStorageName  sql.NullString
+	DestinationCategoryName sql.NullString
+	DestinationShelf        sql.NullString
+	DestinationItemID       sql.NullInt32
 }
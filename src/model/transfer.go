@@ -0,0 +1,69 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// TransferTransaction moves stock of an item to another storage, category or
+// shelf. Approval reserves the quantity at the source and completion moves it
+// to the destination item, which is created when the destination has none.
+type TransferTransaction struct {
+	ID                    uint           `gorm:"primaryKey" json:"id"`
+	UUID                  uuid.UUID      `gorm:"type:uuid;default:uuid_generate_v4();uniqueIndex" json:"uuid"`
+	TransactionType       string         `json:"transaction_type"`
+	SourceItemID          uint           `gorm:"index" json:"source_item_id"`
+	SourceItem            *Item          `gorm:"foreignKey:SourceItemID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;" json:"source_item"`
+	DestinationStorageID  uint           `json:"destination_storage_id"`
+	DestinationCategoryID uint           `json:"destination_category_id"`
+	DestinationCategory   *Category      `gorm:"foreignKey:DestinationCategoryID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;" json:"-"`
+	DestinationShelf      string         `json:"destination_shelf"`
+	DestinationItemID     *uint          `gorm:"index" json:"destination_item_id"`
+	DestinationItem       *Item          `gorm:"foreignKey:DestinationItemID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;" json:"destination_item"`
+	Quantity              int            `json:"quantity"`
+	Status                string         `json:"status"`
+	Notes                 string         `json:"notes"`
+	Time                  time.Time      `json:"time"`
+	CompletedTime         *time.Time     `json:"completed_time"`
+	StatusReason          string         `json:"status_reason"`
+	AdminNote             string         `json:"admin_note"`
+	CreatedBy             *uint          `json:"created_by"`
+	DeletedAt             gorm.DeletedAt `gorm:"index" json:"-"`
+	DeletedBy             *uint          `json:"-"`
+	DeleteReason          string         `json:"-"`
+}
+
+// Create Transfer Transaction
+type CreateTransferTransactionRequest struct {
+	SourceItemID          uint   `json:"source_item_id"`
+	DestinationStorageID  uint   `json:"destination_storage_id"`
+	DestinationCategoryID uint   `json:"destination_category_id"`
+	DestinationShelf      string `json:"destination_shelf"`
+	Quantity              int    `json:"quantity"`
+	Notes                 string `json:"notes"`
+}
+
+type CreateTransferTransactionResponse struct {
+	Message               string `json:"message"`
+	ID                    string `json:"id"`
+	Status                string `json:"status"`
+	SourceItem            *Item  `json:"source_item"`
+	DestinationStorageID  uint   `json:"destination_storage_id"`
+	DestinationCategoryID uint   `json:"destination_category_id"`
+	DestinationShelf      string `json:"destination_shelf"`
+	Quantity              int    `json:"quantity"`
+}
+
+// TransferDetails describes where a transfer moves stock from and to in the
+// transaction listing.
+type TransferDetails struct {
+	SourceItemID          uint   `json:"source_item_id"`
+	SourceItem            *Item  `json:"source_item"`
+	DestinationStorageID  uint   `json:"destination_storage_id"`
+	DestinationCategoryID uint   `json:"destination_category_id"`
+	DestinationShelf      string `json:"destination_shelf"`
+	DestinationItemID     *uint  `json:"destination_item_id"`
+	DestinationItem       *Item  `json:"destination_item"`
+}
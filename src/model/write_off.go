@@ -0,0 +1,60 @@
+package model
+
+import "time"
+
+// WriteOff records loaned units that came back damaged or were lost. It is
+// charged to the employee who borrowed them; the name and department are kept
+// as they were on the loan.
+type WriteOff struct {
+	ID                 uint      `gorm:"primaryKey" json:"id"`
+	LoanTransactionID  uint      `gorm:"index" json:"loan_transaction_id"`
+	LoanReturnID       uint      `json:"loan_return_id"`
+	TransactionLineID  *uint     `json:"line_id"`
+	ItemID             uint      `gorm:"index" json:"item_id"`
+	Item               *Item     `gorm:"foreignKey:ItemID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;" json:"item,omitempty"`
+	EmployeeID         *uint     `gorm:"index" json:"employee_id"`
+	Employee           *Employee `gorm:"foreignKey:EmployeeID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;" json:"-"`
+	EmployeeName       string    `json:"employee_name"`
+	EmployeeDepartment string    `json:"employee_department"`
+	Condition          string    `json:"condition"`
+	Quantity           int       `json:"quantity"`
+	AdminID            *uint     `json:"admin_id"`
+	Time               time.Time `gorm:"index" json:"time"`
+}
+
+// WriteOffFilter narrows down the write-offs and the liability report. Empty
+// fields do not filter.
+type WriteOffFilter struct {
+	EmployeeID *uint
+	Department string
+	Condition  string
+	From       *time.Time
+	To         *time.Time
+}
+
+// Get Write-Offs
+type GetWriteOffsResponse struct {
+	WriteOffs []WriteOff `json:"write_offs"`
+	Total     int64      `json:"total"`
+	Page      int        `json:"page"`
+	Limit     int        `json:"limit"`
+}
+
+// Get Liability Report
+type LiabilityReportResponse struct {
+	GroupBy string           `json:"group_by"`
+	Entries []LiabilityEntry `json:"entries"`
+}
+
+// LiabilityEntry sums the units written off against one employee or
+// department. EmployeeID and EmployeeName are empty when grouping by
+// department.
+type LiabilityEntry struct {
+	EmployeeID   *uint  `json:"employee_id,omitempty"`
+	EmployeeName string `json:"employee_name,omitempty"`
+	Department   string `json:"department"`
+	Damaged      int    `json:"damaged"`
+	Lost         int    `json:"lost"`
+	Total        int    `json:"total"`
+	WriteOffs    int    `json:"write_offs"`
+}
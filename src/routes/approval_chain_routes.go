@@ -0,0 +1,159 @@
+package routes
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"gtihub.com/raditsoic/telkom-storage-ms/src/middleware"
+	"gtihub.com/raditsoic/telkom-storage-ms/src/model"
+	"gtihub.com/raditsoic/telkom-storage-ms/src/service"
+	"gtihub.com/raditsoic/telkom-storage-ms/src/utils"
+)
+
+func ApprovalChainRoutes(r *mux.Router, approvalChainService *service.ApprovalChainService, jwtUtils *utils.JWTUtils) {
+	r.Handle("/api/approval-chains", middleware.AuthMiddleware(jwtUtils, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		chains, err := approvalChainService.GetApprovalChains()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(chains); err != nil {
+			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+			return
+		}
+	}))).Methods("GET")
+
+	r.Handle("/api/approval-chain", middleware.AuthMiddleware(jwtUtils, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req model.ApprovalChainRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		response, err := approvalChainService.CreateApprovalChain(req, middleware.AdminIDFromContext(r.Context()))
+		if err != nil {
+			writeApprovalChainError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+			return
+		}
+	}))).Methods("POST")
+
+	r.Handle("/api/approval-chain/{id}", middleware.AuthMiddleware(jwtUtils, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := mux.Vars(r)["id"]
+
+		chain, err := approvalChainService.GetApprovalChain(id)
+		if err != nil {
+			writeApprovalChainError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(chain); err != nil {
+			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+			return
+		}
+	}))).Methods("GET")
+
+	r.Handle("/api/approval-chain/{id}", middleware.AuthMiddleware(jwtUtils, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := mux.Vars(r)["id"]
+
+		var req model.ApprovalChainRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		response, err := approvalChainService.UpdateApprovalChain(id, req)
+		if err != nil {
+			writeApprovalChainError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+			return
+		}
+	}))).Methods("PUT")
+
+	r.Handle("/api/approval-chain/{id}", middleware.AuthMiddleware(jwtUtils, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := mux.Vars(r)["id"]
+
+		response, err := approvalChainService.DeleteApprovalChain(id)
+		if err != nil {
+			writeApprovalChainError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+			return
+		}
+	}))).Methods("DELETE")
+
+	r.Handle("/api/admin/{id}/roles", middleware.AuthMiddleware(jwtUtils, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := mux.Vars(r)["id"]
+
+		roles, err := approvalChainService.GetAdminRoles(id)
+		if err != nil {
+			writeApprovalChainError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(roles); err != nil {
+			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+			return
+		}
+	}))).Methods("GET")
+
+	r.Handle("/api/admin/{id}/roles", middleware.AuthMiddleware(jwtUtils, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := mux.Vars(r)["id"]
+
+		var req model.AdminRolesRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		roles, err := approvalChainService.SetAdminRoles(id, req)
+		if err != nil {
+			writeApprovalChainError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(roles); err != nil {
+			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+			return
+		}
+	}))).Methods("PUT")
+}
+
+// writeApprovalChainError maps the errors of an approval chain or admin role
+// operation to their HTTP status.
+func writeApprovalChainError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, utils.ErrInvalidID):
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+	case errors.Is(err, utils.ErrInvalidApprovalChain):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, utils.ErrApprovalChainNotFound):
+		http.Error(w, "Approval chain not found", http.StatusNotFound)
+	case errors.Is(err, utils.ErrAdminNotFound):
+		http.Error(w, "Admin not found", http.StatusNotFound)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
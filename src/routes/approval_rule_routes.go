@@ -0,0 +1,148 @@
+package routes
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"gtihub.com/raditsoic/telkom-storage-ms/src/middleware"
+	"gtihub.com/raditsoic/telkom-storage-ms/src/model"
+	"gtihub.com/raditsoic/telkom-storage-ms/src/service"
+	"gtihub.com/raditsoic/telkom-storage-ms/src/utils"
+)
+
+func ApprovalRuleRoutes(r *mux.Router, approvalRuleService *service.ApprovalRuleService, jwtUtils *utils.JWTUtils) {
+	r.Handle("/api/approval-rules", middleware.AuthMiddleware(jwtUtils, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rules, err := approvalRuleService.GetApprovalRules()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(rules); err != nil {
+			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+			return
+		}
+	}))).Methods("GET")
+
+	r.Handle("/api/approval-rules/dry-run", middleware.AuthMiddleware(jwtUtils, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req model.ApprovalRuleDryRunRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		response, err := approvalRuleService.DryRunApprovalRules(req)
+		if err != nil {
+			switch {
+			case errors.Is(err, utils.ErrTransactionType):
+				http.Error(w, "Invalid transaction type, use loan or inquiry", http.StatusBadRequest)
+			case errors.Is(err, utils.ErrInvalidQuantity):
+				http.Error(w, err.Error(), http.StatusBadRequest)
+			case errors.Is(err, utils.ErrItemNotFound), errors.Is(err, utils.ErrEmployeeNotFound):
+				http.Error(w, err.Error(), http.StatusNotFound)
+			default:
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+			}
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+			return
+		}
+	}))).Methods("POST")
+
+	r.Handle("/api/approval-rule", middleware.AuthMiddleware(jwtUtils, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req model.ApprovalRuleRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		response, err := approvalRuleService.CreateApprovalRule(req, middleware.AdminIDFromContext(r.Context()))
+		if err != nil {
+			writeApprovalRuleError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+			return
+		}
+	}))).Methods("POST")
+
+	r.Handle("/api/approval-rule/{id}", middleware.AuthMiddleware(jwtUtils, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := mux.Vars(r)["id"]
+
+		rule, err := approvalRuleService.GetApprovalRule(id)
+		if err != nil {
+			writeApprovalRuleError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(rule); err != nil {
+			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+			return
+		}
+	}))).Methods("GET")
+
+	r.Handle("/api/approval-rule/{id}", middleware.AuthMiddleware(jwtUtils, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := mux.Vars(r)["id"]
+
+		var req model.ApprovalRuleRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		response, err := approvalRuleService.UpdateApprovalRule(id, req)
+		if err != nil {
+			writeApprovalRuleError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+			return
+		}
+	}))).Methods("PUT")
+
+	r.Handle("/api/approval-rule/{id}", middleware.AuthMiddleware(jwtUtils, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := mux.Vars(r)["id"]
+
+		response, err := approvalRuleService.DeleteApprovalRule(id)
+		if err != nil {
+			writeApprovalRuleError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+			return
+		}
+	}))).Methods("DELETE")
+}
+
+// writeApprovalRuleError maps the errors of an approval rule operation to
+// their HTTP status.
+func writeApprovalRuleError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, utils.ErrInvalidID):
+		http.Error(w, "Invalid approval rule ID", http.StatusBadRequest)
+	case errors.Is(err, utils.ErrInvalidApprovalRule):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, utils.ErrApprovalRuleNotFound):
+		http.Error(w, "Approval rule not found", http.StatusNotFound)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,167 @@
+package routes
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"gtihub.com/raditsoic/telkom-storage-ms/src/middleware"
+	"gtihub.com/raditsoic/telkom-storage-ms/src/model"
+	"gtihub.com/raditsoic/telkom-storage-ms/src/service"
+	"gtihub.com/raditsoic/telkom-storage-ms/src/utils"
+)
+
+func EmployeeRoutes(r *mux.Router, employeeService *service.EmployeeService, jwtUtils *utils.JWTUtils) {
+	r.Handle("/api/employees", middleware.AuthMiddleware(jwtUtils, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		if page < 1 {
+			page = 1
+		}
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		if limit < 1 {
+			limit = 10
+		}
+
+		employees, err := employeeService.GetEmployees(r.URL.Query().Get("q"), page, limit)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(employees); err != nil {
+			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+			return
+		}
+	}))).Methods("GET")
+
+	// Autocomplete is public so the request forms can offer the directory.
+	r.HandleFunc("/api/employees/autocomplete", func(w http.ResponseWriter, r *http.Request) {
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		if limit < 1 || limit > 20 {
+			limit = 10
+		}
+
+		suggestions, err := employeeService.SuggestEmployees(r.URL.Query().Get("q"), limit)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(suggestions); err != nil {
+			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+			return
+		}
+	}).Methods("GET")
+
+	r.Handle("/api/employee", middleware.AuthMiddleware(jwtUtils, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req model.EmployeeRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		response, err := employeeService.CreateEmployee(req)
+		if err != nil {
+			writeEmployeeError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+			return
+		}
+	}))).Methods("POST")
+
+	r.Handle("/api/employee/{id}", middleware.AuthMiddleware(jwtUtils, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := mux.Vars(r)["id"]
+
+		employee, err := employeeService.GetEmployee(id)
+		if err != nil {
+			writeEmployeeError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(employee); err != nil {
+			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+			return
+		}
+	}))).Methods("GET")
+
+	r.Handle("/api/employee/{id}", middleware.AuthMiddleware(jwtUtils, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := mux.Vars(r)["id"]
+
+		var req model.EmployeeRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		response, err := employeeService.UpdateEmployee(id, req)
+		if err != nil {
+			writeEmployeeError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+			return
+		}
+	}))).Methods("PUT")
+
+	r.Handle("/api/employee/{id}", middleware.AuthMiddleware(jwtUtils, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := mux.Vars(r)["id"]
+
+		response, err := employeeService.DeleteEmployee(id)
+		if err != nil {
+			writeEmployeeError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+			return
+		}
+	}))).Methods("DELETE")
+
+	r.Handle("/api/employee/{id}/activity", middleware.AuthMiddleware(jwtUtils, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := mux.Vars(r)["id"]
+
+		activity, err := employeeService.GetEmployeeActivity(id)
+		if err != nil {
+			writeEmployeeError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(activity); err != nil {
+			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+			return
+		}
+	}))).Methods("GET")
+}
+
+// writeEmployeeError maps the errors of an employee operation to their HTTP
+// status.
+func writeEmployeeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, utils.ErrInvalidID):
+		http.Error(w, "Invalid employee ID", http.StatusBadRequest)
+	case errors.Is(err, utils.ErrEmployeeNameRequired):
+		http.Error(w, "Employee name is required", http.StatusBadRequest)
+	case errors.Is(err, utils.ErrEmployeeNotFound):
+		http.Error(w, "Employee not found", http.StatusNotFound)
+	case errors.Is(err, utils.ErrEmployeeIDNumberExists):
+		http.Error(w, "Employee ID number already exists", http.StatusConflict)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
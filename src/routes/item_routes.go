@@ -3,9 +3,11 @@ package routes
 import (
 	"encoding/csv"
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gorilla/mux"
 	"gtihub.com/raditsoic/telkom-storage-ms/src/middleware"
@@ -58,6 +60,10 @@ func ItemRoutes(r *mux.Router, itemService *service.ItemService, jwtUtils *utils
 				http.Error(w, err.Error(), http.StatusNotFound)
 				return
 			}
+			if err == utils.ErrItemInUse {
+				http.Error(w, err.Error(), http.StatusConflict)
+				return
+			}
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
@@ -76,7 +82,7 @@ func ItemRoutes(r *mux.Router, itemService *service.ItemService, jwtUtils *utils
 			return
 		}
 
-		createdItem, err := itemService.CreateItem(&item)
+		createdItem, err := itemService.CreateItem(&item, middleware.AdminIDFromContext(r.Context()))
 		if err != nil {
 			log.Printf("Error creating item: %v", err)
 			http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -126,6 +132,56 @@ func ItemRoutes(r *mux.Router, itemService *service.ItemService, jwtUtils *utils
 		}
 	}))).Methods("PATCH")
 
+	r.Handle("/api/item/{id}/movements", middleware.AuthMiddleware(jwtUtils, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := mux.Vars(r)["id"]
+
+		movements, err := itemService.GetItemMovements(id, r.URL.Query().Get("page"), r.URL.Query().Get("limit"))
+		if err != nil {
+			if errors.Is(err, utils.ErrItemNotFound) {
+				http.Error(w, err.Error(), http.StatusNotFound)
+				return
+			}
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(movements); err != nil {
+			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+			return
+		}
+	}))).Methods("GET")
+
+	r.Handle("/api/item/{id}/balance", middleware.AuthMiddleware(jwtUtils, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := mux.Vars(r)["id"]
+
+		at := time.Now()
+		if atParam := r.URL.Query().Get("at"); atParam != "" {
+			parsed, err := time.Parse(time.RFC3339, atParam)
+			if err != nil {
+				http.Error(w, "Invalid time format. Use RFC3339 (e.g., 2024-12-05T00:00:00Z)", http.StatusBadRequest)
+				return
+			}
+			at = parsed
+		}
+
+		balance, err := itemService.GetItemBalance(id, at)
+		if err != nil {
+			if errors.Is(err, utils.ErrItemNotFound) || errors.Is(err, utils.ErrItemBalanceNotFound) {
+				http.Error(w, err.Error(), http.StatusNotFound)
+				return
+			}
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(balance); err != nil {
+			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+			return
+		}
+	}))).Methods("GET")
+
 	r.HandleFunc("/api/items/export", func(w http.ResponseWriter, r *http.Request) {
 		items, err := itemService.ExportItems()
 		if err != nil {
@@ -0,0 +1,133 @@
+package routes
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"gtihub.com/raditsoic/telkom-storage-ms/src/middleware"
+	"gtihub.com/raditsoic/telkom-storage-ms/src/model"
+	"gtihub.com/raditsoic/telkom-storage-ms/src/service"
+	"gtihub.com/raditsoic/telkom-storage-ms/src/utils"
+)
+
+func QuotaRoutes(r *mux.Router, quotaService *service.QuotaService, jwtUtils *utils.JWTUtils) {
+	// Lists every quota along with how much of it has been used.
+	r.Handle("/api/quotas", middleware.AuthMiddleware(jwtUtils, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var employeeID *uint
+		if value := r.URL.Query().Get("employee_id"); value != "" {
+			id, err := strconv.ParseUint(value, 10, 32)
+			if err != nil {
+				http.Error(w, "Invalid employee ID", http.StatusBadRequest)
+				return
+			}
+			parsed := uint(id)
+			employeeID = &parsed
+		}
+
+		usages, err := quotaService.GetQuotaUsages(employeeID, r.URL.Query().Get("department"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(usages); err != nil {
+			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+			return
+		}
+	}))).Methods("GET")
+
+	r.Handle("/api/quota", middleware.AuthMiddleware(jwtUtils, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req model.QuotaRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		response, err := quotaService.CreateQuota(req, middleware.AdminIDFromContext(r.Context()))
+		if err != nil {
+			writeQuotaError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+			return
+		}
+	}))).Methods("POST")
+
+	r.Handle("/api/quota/{id}", middleware.AuthMiddleware(jwtUtils, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := mux.Vars(r)["id"]
+
+		usage, err := quotaService.GetQuotaUsage(id)
+		if err != nil {
+			writeQuotaError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(usage); err != nil {
+			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+			return
+		}
+	}))).Methods("GET")
+
+	r.Handle("/api/quota/{id}", middleware.AuthMiddleware(jwtUtils, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := mux.Vars(r)["id"]
+
+		var req model.QuotaRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		response, err := quotaService.UpdateQuota(id, req)
+		if err != nil {
+			writeQuotaError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+			return
+		}
+	}))).Methods("PUT")
+
+	r.Handle("/api/quota/{id}", middleware.AuthMiddleware(jwtUtils, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := mux.Vars(r)["id"]
+
+		response, err := quotaService.DeleteQuota(id)
+		if err != nil {
+			writeQuotaError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+			return
+		}
+	}))).Methods("DELETE")
+}
+
+// writeQuotaError maps the errors of a quota operation to their HTTP status.
+func writeQuotaError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, utils.ErrInvalidID):
+		http.Error(w, "Invalid quota ID", http.StatusBadRequest)
+	case errors.Is(err, utils.ErrInvalidQuota):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, utils.ErrQuotaNotFound):
+		http.Error(w, "Quota not found", http.StatusNotFound)
+	case errors.Is(err, utils.ErrQuotaTargetNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
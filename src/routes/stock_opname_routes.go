@@ -0,0 +1,174 @@
+package routes
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"gtihub.com/raditsoic/telkom-storage-ms/src/middleware"
+	"gtihub.com/raditsoic/telkom-storage-ms/src/model"
+	"gtihub.com/raditsoic/telkom-storage-ms/src/service"
+	"gtihub.com/raditsoic/telkom-storage-ms/src/utils"
+)
+
+func StockOpnameRoutes(r *mux.Router, stockOpnameService *service.StockOpnameService, jwtUtils *utils.JWTUtils) {
+	r.Handle("/api/storage/{id}/stock-opname", middleware.AuthMiddleware(jwtUtils, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := mux.Vars(r)["id"]
+
+		var req model.OpenStockOpnameRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		response, err := stockOpnameService.OpenStockOpname(id, req, middleware.AdminIDFromContext(r.Context()))
+		if err != nil {
+			if errors.Is(err, utils.ErrInvalidID) {
+				http.Error(w, "Invalid storage ID", http.StatusBadRequest)
+				return
+			}
+			if errors.Is(err, utils.ErrStorageNotFound) {
+				http.Error(w, "Storage not found", http.StatusNotFound)
+				return
+			}
+			if errors.Is(err, utils.ErrStockOpnameInProgress) {
+				http.Error(w, "Storage already has an open stock opname", http.StatusConflict)
+				return
+			}
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+			return
+		}
+	}))).Methods("POST")
+
+	r.Handle("/api/stock-opnames", middleware.AuthMiddleware(jwtUtils, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		opnames, err := stockOpnameService.GetStockOpnames()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(opnames); err != nil {
+			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+			return
+		}
+	}))).Methods("GET")
+
+	r.Handle("/api/stock-opname/{id}", middleware.AuthMiddleware(jwtUtils, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := mux.Vars(r)["id"]
+
+		opname, err := stockOpnameService.GetStockOpname(id)
+		if err != nil {
+			writeStockOpnameError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(opname); err != nil {
+			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+			return
+		}
+	}))).Methods("GET")
+
+	r.Handle("/api/stock-opname/{id}/counts", middleware.AuthMiddleware(jwtUtils, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := mux.Vars(r)["id"]
+
+		var req model.RecordStockOpnameCountsRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		response, err := stockOpnameService.RecordStockOpnameCounts(id, req, middleware.AdminIDFromContext(r.Context()))
+		if err != nil {
+			writeStockOpnameError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+			return
+		}
+	}))).Methods("POST")
+
+	r.Handle("/api/stock-opname/{id}/variance", middleware.AuthMiddleware(jwtUtils, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := mux.Vars(r)["id"]
+
+		variance, err := stockOpnameService.GetStockOpnameVariance(id)
+		if err != nil {
+			writeStockOpnameError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(variance); err != nil {
+			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+			return
+		}
+	}))).Methods("GET")
+
+	r.Handle("/api/stock-opname/{id}/commit", middleware.AuthMiddleware(jwtUtils, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := mux.Vars(r)["id"]
+
+		response, err := stockOpnameService.CommitStockOpname(id, middleware.AdminIDFromContext(r.Context()))
+		if err != nil {
+			writeStockOpnameError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+			return
+		}
+	}))).Methods("POST")
+
+	r.Handle("/api/stock-opname/{id}/cancel", middleware.AuthMiddleware(jwtUtils, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := mux.Vars(r)["id"]
+
+		response, err := stockOpnameService.CancelStockOpname(id, middleware.AdminIDFromContext(r.Context()))
+		if err != nil {
+			writeStockOpnameError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+			return
+		}
+	}))).Methods("POST")
+}
+
+// writeStockOpnameError maps the errors of a stock opname operation to their
+// HTTP status.
+func writeStockOpnameError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, utils.ErrInvalidID):
+		http.Error(w, "Invalid stock opname ID", http.StatusBadRequest)
+	case errors.Is(err, utils.ErrCounterRequired), errors.Is(err, utils.ErrInvalidCount):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, utils.ErrStockOpnameNotFound):
+		http.Error(w, "Stock opname not found", http.StatusNotFound)
+	case errors.Is(err, utils.ErrItemNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, utils.ErrCountBelowReserved):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, utils.ErrStockOpnameClosed):
+		http.Error(w, "Stock opname is no longer open", http.StatusConflict)
+	case errors.Is(err, utils.ErrInsufficientQuantity):
+		http.Error(w, "Counted quantity is below what approved transactions already reserved", http.StatusConflict)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
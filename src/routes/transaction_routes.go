@@ -9,6 +9,7 @@ import (
 	"log"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gorilla/mux"
@@ -18,7 +19,7 @@ import (
 	"gtihub.com/raditsoic/telkom-storage-ms/src/utils"
 )
 
-func TransactionRoutes(r *mux.Router, transactionService *service.TransactionService, jwtUtils *utils.JWTUtils) {
+func TransactionRoutes(r *mux.Router, transactionService *service.TransactionService, idempotencyService *service.IdempotencyService, jwtUtils *utils.JWTUtils) {
 	r.HandleFunc("/api/transactions", func(w http.ResponseWriter, r *http.Request) {
 		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
 		if page < 1 {
@@ -29,8 +30,30 @@ func TransactionRoutes(r *mux.Router, transactionService *service.TransactionSer
 			limit = 10
 		}
 
-		transactions, err := transactionService.GetTransactions(page, limit)
+		filter, err := parseTransactionFilter(r)
 		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		transactions, err := transactionService.GetTransactions(filter, r.URL.Query().Get("cursor"), page, limit)
+		if err != nil {
+			if errors.Is(err, utils.ErrTransactionType) {
+				http.Error(w, "Invalid transaction type", http.StatusBadRequest)
+				return
+			}
+			if errors.Is(err, utils.ErrInvalidSort) {
+				http.Error(w, "Invalid sort, use time, status or employee", http.StatusBadRequest)
+				return
+			}
+			if errors.Is(err, utils.ErrInvalidCursor) {
+				http.Error(w, "Invalid cursor", http.StatusBadRequest)
+				return
+			}
+			if errors.Is(err, utils.ErrCursorOrderMismatch) {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
@@ -42,7 +65,95 @@ func TransactionRoutes(r *mux.Router, transactionService *service.TransactionSer
 		}
 	}).Methods("GET")
 
-	r.HandleFunc("/api/transaction/loan", func(w http.ResponseWriter, r *http.Request) {
+	r.Handle("/api/transactions/trash", middleware.AuthMiddleware(jwtUtils, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		if page < 1 {
+			page = 1
+		}
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		if limit < 1 {
+			limit = 10
+		}
+
+		filter, err := parseTransactionFilter(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		filter.Deleted = true
+
+		transactions, err := transactionService.GetTransactions(filter, r.URL.Query().Get("cursor"), page, limit)
+		if err != nil {
+			if errors.Is(err, utils.ErrTransactionType) {
+				http.Error(w, "Invalid transaction type", http.StatusBadRequest)
+				return
+			}
+			if errors.Is(err, utils.ErrInvalidSort) {
+				http.Error(w, "Invalid sort, use time, status or employee", http.StatusBadRequest)
+				return
+			}
+			if errors.Is(err, utils.ErrInvalidCursor) {
+				http.Error(w, "Invalid cursor", http.StatusBadRequest)
+				return
+			}
+			if errors.Is(err, utils.ErrCursorOrderMismatch) {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(transactions); err != nil {
+			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+			return
+		}
+	}))).Methods("GET")
+
+	r.Handle("/api/transactions/bulk-status", middleware.AuthMiddleware(jwtUtils, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req model.BulkUpdateTransactionStatusRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		response, err := transactionService.BulkUpdateTransactionStatus(req, middleware.AdminIDFromContext(r.Context()))
+		if err != nil {
+			if errors.Is(err, utils.ErrInvalidBulkRequest) {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			if errors.Is(err, utils.ErrReasonRequired) {
+				http.Error(w, "Reason is required when rejecting a request or marking it incomplete", http.StatusBadRequest)
+				return
+			}
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+			return
+		}
+	}))).Methods("POST")
+
+	r.Handle("/api/transactions/overdue", middleware.AuthMiddleware(jwtUtils, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		loans, err := transactionService.GetOverdueLoans()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(loans); err != nil {
+			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+			return
+		}
+	}))).Methods("GET")
+
+	r.Handle("/api/transaction/loan", middleware.IdempotencyMiddleware(idempotencyService, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		var req model.LoanTransaction
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 			http.Error(w, "Invalid request body", http.StatusBadRequest)
@@ -51,6 +162,22 @@ func TransactionRoutes(r *mux.Router, transactionService *service.TransactionSer
 
 		transaction, err := transactionService.CreateLoanTransaction(req)
 		if err != nil {
+			if errors.Is(err, utils.ErrItemNotFound) {
+				http.Error(w, err.Error(), http.StatusNotFound)
+				return
+			}
+			if errors.Is(err, utils.ErrInvalidQuantity) {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			if errors.Is(err, utils.ErrStockOpnameInProgress) {
+				http.Error(w, "Storage of a requested item is being counted, try again once the stock opname is closed", http.StatusConflict)
+				return
+			}
+			if errors.Is(err, utils.ErrEmployeeNotFound) {
+				http.Error(w, "Employee not found", http.StatusNotFound)
+				return
+			}
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
@@ -61,9 +188,9 @@ func TransactionRoutes(r *mux.Router, transactionService *service.TransactionSer
 			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
 			return
 		}
-	}).Methods("POST")
+	}))).Methods("POST")
 
-	r.HandleFunc("/api/transaction/inquiry", func(w http.ResponseWriter, r *http.Request) {
+	r.Handle("/api/transaction/inquiry", middleware.IdempotencyMiddleware(idempotencyService, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		var req model.InquiryTransaction
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 			http.Error(w, "Invalid request body", http.StatusBadRequest)
@@ -72,6 +199,26 @@ func TransactionRoutes(r *mux.Router, transactionService *service.TransactionSer
 
 		transaction, err := transactionService.CreateInquiryTransaction(req)
 		if err != nil {
+			if errors.Is(err, utils.ErrItemNotFound) {
+				http.Error(w, err.Error(), http.StatusNotFound)
+				return
+			}
+			if errors.Is(err, utils.ErrInvalidQuantity) {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			if errors.Is(err, utils.ErrStockOpnameInProgress) {
+				http.Error(w, "Storage of a requested item is being counted, try again once the stock opname is closed", http.StatusConflict)
+				return
+			}
+			if errors.Is(err, utils.ErrQuotaExceeded) {
+				http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+				return
+			}
+			if errors.Is(err, utils.ErrEmployeeNotFound) {
+				http.Error(w, "Employee not found", http.StatusNotFound)
+				return
+			}
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
@@ -82,9 +229,9 @@ func TransactionRoutes(r *mux.Router, transactionService *service.TransactionSer
 			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
 			return
 		}
-	}).Methods("POST")
+	}))).Methods("POST")
 
-	r.HandleFunc("/api/transaction/insert", func(w http.ResponseWriter, r *http.Request) {
+	r.Handle("/api/transaction/insert", middleware.IdempotencyMiddleware(idempotencyService, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if err := r.ParseMultipartForm(10 << 20); err != nil {
 			http.Error(w, "Unable to parse form", http.StatusBadRequest)
 			return
@@ -96,14 +243,27 @@ func TransactionRoutes(r *mux.Router, transactionService *service.TransactionSer
 		}
 
 		requiredFields := map[string]string{
-			"employee_name":       r.FormValue("employee_name"),
-			"employee_department": r.FormValue("employee_department"),
-			"employee_position":   r.FormValue("employee_position"),
-			"notes":               r.FormValue("notes"),
-			"item_name":           r.FormValue("item_name"),
-			"quantity":            r.FormValue("quantity"),
-			"shelf":               r.FormValue("shelf"),
-			"category_id":         r.FormValue("category_id"),
+			"notes":       r.FormValue("notes"),
+			"item_name":   r.FormValue("item_name"),
+			"quantity":    r.FormValue("quantity"),
+			"shelf":       r.FormValue("shelf"),
+			"category_id": r.FormValue("category_id"),
+		}
+
+		// Employees picked from the directory bring their own details.
+		var employeeID *uint
+		if value := r.FormValue("employee_id"); value != "" {
+			id, err := strconv.ParseUint(value, 10, 32)
+			if err != nil {
+				http.Error(w, "Invalid employee ID: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+			parsed := uint(id)
+			employeeID = &parsed
+		} else {
+			requiredFields["employee_name"] = r.FormValue("employee_name")
+			requiredFields["employee_department"] = r.FormValue("employee_department")
+			requiredFields["employee_position"] = r.FormValue("employee_position")
 		}
 
 		for field, value := range requiredFields {
@@ -159,6 +319,7 @@ func TransactionRoutes(r *mux.Router, transactionService *service.TransactionSer
 			EmployeeName:       r.FormValue("employee_name"),
 			EmployeeDepartment: r.FormValue("employee_department"),
 			EmployeePosition:   r.FormValue("employee_position"),
+			EmployeeID:         employeeID,
 			Notes:              r.FormValue("notes"),
 			Image:              imageData,
 			ItemRequest: model.ItemRequestDTO{
@@ -171,6 +332,14 @@ func TransactionRoutes(r *mux.Router, transactionService *service.TransactionSer
 
 		transaction, err := transactionService.CreateInsertionTransaction(&req)
 		if err != nil {
+			if errors.Is(err, utils.ErrStockOpnameInProgress) {
+				http.Error(w, "Storage of the category is being counted, try again once the stock opname is closed", http.StatusConflict)
+				return
+			}
+			if errors.Is(err, utils.ErrEmployeeNotFound) {
+				http.Error(w, "Employee not found", http.StatusNotFound)
+				return
+			}
 			log.Printf("Error creating insertion transaction: %v", err)
 			http.Error(w, "Failed to create transaction: "+err.Error(), http.StatusInternalServerError)
 			return
@@ -183,7 +352,48 @@ func TransactionRoutes(r *mux.Router, transactionService *service.TransactionSer
 			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
 			return
 		}
-	}).Methods("POST")
+	}))).Methods("POST")
+
+	r.Handle("/api/transaction/transfer", middleware.AuthMiddleware(jwtUtils, middleware.IdempotencyMiddleware(idempotencyService, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req model.CreateTransferTransactionRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		transaction, err := transactionService.CreateTransferTransaction(req, middleware.AdminIDFromContext(r.Context()))
+		if err != nil {
+			if errors.Is(err, utils.ErrInvalidQuantity) || errors.Is(err, utils.ErrInvalidTransfer) {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			if errors.Is(err, utils.ErrItemNotFound) {
+				http.Error(w, err.Error(), http.StatusNotFound)
+				return
+			}
+			if errors.Is(err, utils.ErrCategoryNotFound) {
+				http.Error(w, "Destination category not found", http.StatusNotFound)
+				return
+			}
+			if errors.Is(err, utils.ErrInsufficientQuantity) {
+				http.Error(w, "Insufficient item quantity", http.StatusConflict)
+				return
+			}
+			if errors.Is(err, utils.ErrStockOpnameInProgress) {
+				http.Error(w, "Storage of the item or the destination is being counted, try again once the stock opname is closed", http.StatusConflict)
+				return
+			}
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		if err := json.NewEncoder(w).Encode(transaction); err != nil {
+			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+			return
+		}
+	})))).Methods("POST")
 
 	// r.Handle("/api/transaction/{uuid}/{status}", middleware.AuthMiddleware(jwtUtils, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 	// 	uuid := mux.Vars(r)["uuid"]
@@ -208,8 +418,35 @@ func TransactionRoutes(r *mux.Router, transactionService *service.TransactionSer
 	r.Handle("/api/transaction/{uuid}/{status}", middleware.AuthMiddleware(jwtUtils, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		uuid := mux.Vars(r)["uuid"]
 		status := mux.Vars(r)["status"]
-		transaction, err := transactionService.UpdateTransactionStatus(status, uuid)
+
+		var req model.UpdateTransactionStatusRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		transaction, err := transactionService.UpdateTransactionStatus(status, uuid, middleware.AdminIDFromContext(r.Context()), req)
 		if err != nil {
+			if errors.Is(err, utils.ErrReasonRequired) {
+				http.Error(w, "Reason is required when rejecting a request or marking it incomplete", http.StatusBadRequest)
+				return
+			}
+			if errors.Is(err, utils.ErrItemChoiceConflict) {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			if errors.Is(err, utils.ErrInvalidReturnCondition) {
+				http.Error(w, "Damaged and lost units must not be negative, exceed the outstanding quantity or repeat a line", http.StatusBadRequest)
+				return
+			}
+			if errors.Is(err, utils.ErrLineNotFound) {
+				http.Error(w, "Transaction line not found, line_id is required in conditions when several lines are out", http.StatusNotFound)
+				return
+			}
+			if errors.Is(err, utils.ErrSimilarItemExists) {
+				http.Error(w, "Similar items exist, see /api/transaction/"+uuid+"/item-matches and complete with item_id or create_new_item", http.StatusConflict)
+				return
+			}
 			if errors.Is(err, utils.ErrTransactionType) {
 				http.Error(w, "Invalid transaction type", http.StatusBadRequest)
 				return
@@ -218,6 +455,34 @@ func TransactionRoutes(r *mux.Router, transactionService *service.TransactionSer
 				http.Error(w, "Transaction not found", http.StatusNotFound)
 				return
 			}
+			if errors.Is(err, utils.ErrItemNotFound) {
+				http.Error(w, "Item not found", http.StatusNotFound)
+				return
+			}
+			if errors.Is(err, utils.ErrInsufficientQuantity) {
+				http.Error(w, "Insufficient item quantity", http.StatusConflict)
+				return
+			}
+			if errors.Is(err, utils.ErrInvalidStatus) {
+				http.Error(w, "Invalid status", http.StatusBadRequest)
+				return
+			}
+			if errors.Is(err, utils.ErrInvalidTransition) {
+				http.Error(w, err.Error(), http.StatusConflict)
+				return
+			}
+			if errors.Is(err, utils.ErrApprovalStepsPending) {
+				http.Error(w, "Approval chain has steps waiting for a decision, see /api/transaction/"+uuid+"/approvals", http.StatusConflict)
+				return
+			}
+			if errors.Is(err, utils.ErrStockOpnameInProgress) {
+				http.Error(w, "Storage is being counted, try again once the stock opname is closed", http.StatusConflict)
+				return
+			}
+			if errors.Is(err, utils.ErrInvalidTransfer) {
+				http.Error(w, err.Error(), http.StatusConflict)
+				return
+			}
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
@@ -229,14 +494,49 @@ func TransactionRoutes(r *mux.Router, transactionService *service.TransactionSer
 		}
 	}))).Methods("PATCH")
 
-	r.Handle("/api/transaction/{uuid}", middleware.AuthMiddleware(jwtUtils, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	r.Handle("/api/transaction/{uuid}/return", middleware.AuthMiddleware(jwtUtils, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		uuid := mux.Vars(r)["uuid"]
-		response, err := transactionService.DeleteTransaction(uuid)
+
+		var req model.ReturnLoanRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		response, err := transactionService.ReturnLoanTransaction(uuid, req, middleware.AdminIDFromContext(r.Context()))
 		if err != nil {
+			if errors.Is(err, utils.ErrTransactionType) {
+				http.Error(w, "Only loan transactions can be returned", http.StatusBadRequest)
+				return
+			}
 			if errors.Is(err, utils.ErrTransactionNotFound) {
 				http.Error(w, "Transaction not found", http.StatusNotFound)
 				return
 			}
+			if errors.Is(err, utils.ErrItemNotFound) {
+				http.Error(w, "Item not found", http.StatusNotFound)
+				return
+			}
+			if errors.Is(err, utils.ErrLineNotFound) {
+				http.Error(w, "Transaction line not found, line_id is required when several lines are out", http.StatusNotFound)
+				return
+			}
+			if errors.Is(err, utils.ErrInvalidReturnQuantity) {
+				http.Error(w, "Return quantity must be between 1 and the outstanding quantity", http.StatusBadRequest)
+				return
+			}
+			if errors.Is(err, utils.ErrInvalidReturnCondition) {
+				http.Error(w, "Damaged and lost units must not be negative or exceed the returned quantity", http.StatusBadRequest)
+				return
+			}
+			if errors.Is(err, utils.ErrStockOpnameInProgress) {
+				http.Error(w, "Storage of the item is being counted, try again once the stock opname is closed", http.StatusConflict)
+				return
+			}
+			if errors.Is(err, utils.ErrInvalidTransition) {
+				http.Error(w, err.Error(), http.StatusConflict)
+				return
+			}
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
@@ -246,68 +546,550 @@ func TransactionRoutes(r *mux.Router, transactionService *service.TransactionSer
 			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
 			return
 		}
-	}))).Methods("DELETE")
+	}))).Methods("POST")
 
-	r.HandleFunc("/api/transactions/export", func(w http.ResponseWriter, r *http.Request) {
-		startTimeParam := r.URL.Query().Get("from")
-		endTimeParam := r.URL.Query().Get("to")
+	r.HandleFunc("/api/transaction/{uuid}/extensions", func(w http.ResponseWriter, r *http.Request) {
+		uuid := mux.Vars(r)["uuid"]
 
-		var startTime, endTime time.Time
-    	var err error
+		token := r.Header.Get("X-Tracking-Token")
 
-		if startTimeParam == "" {
-        // Default to a reasonable past time, e.g., 1 year ago
-			startTime = time.Now().AddDate(-1, 0, 0)
-		} else {
-			startTime, err = time.Parse(time.RFC3339, startTimeParam)
-			if err != nil {
-				http.Error(w, "Invalid start time format. Use RFC3339 (e.g., 2024-12-05T00:00:00Z)", http.StatusBadRequest)
-				return
-			}
+		var req model.CreateLoanExtensionRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
 		}
 
-		if endTimeParam == "" {
-			endTime = time.Now()
-		} else {
-			endTime, err = time.Parse(time.RFC3339, endTimeParam)
-			if err != nil {
-				http.Error(w, "Invalid end time format. Use RFC3339 (e.g., 2024-12-05T23:59:59Z)", http.StatusBadRequest)
+		response, err := transactionService.RequestLoanExtension(uuid, token, req)
+		if err != nil {
+			if errors.Is(err, utils.ErrTrackingTokenRequired) {
+				http.Error(w, "Tracking token is required", http.StatusUnauthorized)
+				return
+			}
+			if errors.Is(err, utils.ErrTransactionType) {
+				http.Error(w, "Only loan transactions can be extended", http.StatusBadRequest)
+				return
+			}
+			if errors.Is(err, utils.ErrTransactionNotFound) {
+				http.Error(w, "Transaction not found", http.StatusNotFound)
+				return
+			}
+			if errors.Is(err, utils.ErrReasonRequired) {
+				http.Error(w, "Reason is required", http.StatusBadRequest)
+				return
+			}
+			if errors.Is(err, utils.ErrInvalidReturnTime) {
+				http.Error(w, "Return time must be after the current return time", http.StatusBadRequest)
+				return
+			}
+			if errors.Is(err, utils.ErrExtensionPending) || errors.Is(err, utils.ErrInvalidTransition) {
+				http.Error(w, err.Error(), http.StatusConflict)
 				return
 			}
-    	}
-
-		transactions, err := transactionService.ExportTransactions(startTime, endTime)
-		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
 
-		w.Header().Set("Content-Disposition", "attachment; filename=combined_transactions.csv")
-		w.Header().Set("Content-Type", "text/csv")
-
-		writer := csv.NewWriter(w)
-		defer writer.Flush()
-
-		header := []string{
-			"TransactionType", "ID", "UUID", "EmployeeName", "EmployeeDepartment", "EmployeePosition",
-			"CategoryName", "ItemName", "Quantity", "Status", "Notes", "Time", "ItemID",
-			"LoanTime", "ReturnTime", "CompletedTime", "ReturnedTime", "Image",
-		}
-		if err := writer.Write(header); err != nil {
-			http.Error(w, "Failed to write CSV header", http.StatusInternalServerError)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
 			return
 		}
+	}).Methods("POST")
 
-		for _, t := range transactions {
-			row := []string{
-				t.TransactionType,
-				fmt.Sprintf("%d", t.ID),
-				t.UUID,
-				t.EmployeeName,
-				t.EmployeeDepartment,
-				t.EmployeePosition,
-				t.CategoryName.String,
-				t.ItemName.String,
+	r.Handle("/api/transaction/{uuid}/extensions", middleware.AuthMiddleware(jwtUtils, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		uuid := mux.Vars(r)["uuid"]
+		extensions, err := transactionService.GetLoanExtensions(uuid)
+		if err != nil {
+			if errors.Is(err, utils.ErrTransactionType) {
+				http.Error(w, "Only loan transactions can be extended", http.StatusBadRequest)
+				return
+			}
+			if errors.Is(err, utils.ErrTransactionNotFound) {
+				http.Error(w, "Transaction not found", http.StatusNotFound)
+				return
+			}
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(extensions); err != nil {
+			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+			return
+		}
+	}))).Methods("GET")
+
+	r.Handle("/api/transaction/{uuid}/extensions/{id}/{status}", middleware.AuthMiddleware(jwtUtils, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		response, err := transactionService.DecideLoanExtension(vars["uuid"], vars["id"], vars["status"], middleware.AdminIDFromContext(r.Context()))
+		if err != nil {
+			if errors.Is(err, utils.ErrTransactionType) {
+				http.Error(w, "Only loan transactions can be extended", http.StatusBadRequest)
+				return
+			}
+			if errors.Is(err, utils.ErrInvalidID) {
+				http.Error(w, "Invalid ID", http.StatusBadRequest)
+				return
+			}
+			if errors.Is(err, utils.ErrInvalidStatus) {
+				http.Error(w, "Invalid status", http.StatusBadRequest)
+				return
+			}
+			if errors.Is(err, utils.ErrTransactionNotFound) {
+				http.Error(w, "Transaction not found", http.StatusNotFound)
+				return
+			}
+			if errors.Is(err, utils.ErrExtensionNotFound) {
+				http.Error(w, "Loan extension not found", http.StatusNotFound)
+				return
+			}
+			if errors.Is(err, utils.ErrInvalidTransition) {
+				http.Error(w, err.Error(), http.StatusConflict)
+				return
+			}
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+			return
+		}
+	}))).Methods("PATCH")
+
+	r.Handle("/api/transaction/{uuid}/lines/{line_id}/{status}", middleware.AuthMiddleware(jwtUtils, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		var req model.UpdateTransactionStatusRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		transaction, err := transactionService.UpdateTransactionLineStatus(vars["status"], vars["uuid"], vars["line_id"], middleware.AdminIDFromContext(r.Context()), req)
+		if err != nil {
+			if errors.Is(err, utils.ErrReasonRequired) {
+				http.Error(w, "Reason is required when rejecting a line", http.StatusBadRequest)
+				return
+			}
+			if errors.Is(err, utils.ErrTransactionType) {
+				http.Error(w, "Only loan and inquiry transactions have lines", http.StatusBadRequest)
+				return
+			}
+			if errors.Is(err, utils.ErrInvalidID) {
+				http.Error(w, "Invalid line ID", http.StatusBadRequest)
+				return
+			}
+			if errors.Is(err, utils.ErrTransactionNotFound) {
+				http.Error(w, "Transaction not found", http.StatusNotFound)
+				return
+			}
+			if errors.Is(err, utils.ErrLineNotFound) {
+				http.Error(w, "Transaction line not found", http.StatusNotFound)
+				return
+			}
+			if errors.Is(err, utils.ErrStockOpnameInProgress) {
+				http.Error(w, "Storage of the item is being counted, try again once the stock opname is closed", http.StatusConflict)
+				return
+			}
+			if errors.Is(err, utils.ErrItemNotFound) {
+				http.Error(w, "Item not found", http.StatusNotFound)
+				return
+			}
+			if errors.Is(err, utils.ErrInsufficientQuantity) {
+				http.Error(w, "Insufficient item quantity", http.StatusConflict)
+				return
+			}
+			if errors.Is(err, utils.ErrInvalidStatus) {
+				http.Error(w, "Invalid status", http.StatusBadRequest)
+				return
+			}
+			if errors.Is(err, utils.ErrInvalidTransition) {
+				http.Error(w, err.Error(), http.StatusConflict)
+				return
+			}
+			if errors.Is(err, utils.ErrApprovalStepsPending) {
+				http.Error(w, "Approval chain has steps waiting for a decision, see /api/transaction/"+vars["uuid"]+"/approvals", http.StatusConflict)
+				return
+			}
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(transaction); err != nil {
+			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+			return
+		}
+	}))).Methods("PATCH")
+
+	r.Handle("/api/transaction/{uuid}/transitions", middleware.AuthMiddleware(jwtUtils, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		uuid := mux.Vars(r)["uuid"]
+		transitions, err := transactionService.GetTransactionTransitions(uuid)
+		if err != nil {
+			if errors.Is(err, utils.ErrTransactionType) {
+				http.Error(w, "Invalid transaction type", http.StatusBadRequest)
+				return
+			}
+			if errors.Is(err, utils.ErrTransactionNotFound) {
+				http.Error(w, "Transaction not found", http.StatusNotFound)
+				return
+			}
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(transitions); err != nil {
+			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+			return
+		}
+	}))).Methods("GET")
+
+	r.Handle("/api/transaction/{uuid}/approvals", middleware.AuthMiddleware(jwtUtils, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		uuid := mux.Vars(r)["uuid"]
+		approvals, err := transactionService.GetTransactionApprovals(uuid)
+		if err != nil {
+			if errors.Is(err, utils.ErrTransactionType) {
+				http.Error(w, "Only loan and inquiry transactions have approval chains", http.StatusBadRequest)
+				return
+			}
+			if errors.Is(err, utils.ErrTransactionNotFound) {
+				http.Error(w, "Transaction not found", http.StatusNotFound)
+				return
+			}
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(approvals); err != nil {
+			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+			return
+		}
+	}))).Methods("GET")
+
+	r.Handle("/api/transaction/{uuid}/approvals/{status}", middleware.AuthMiddleware(jwtUtils, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		var req model.UpdateTransactionStatusRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		approvals, err := transactionService.DecideApprovalStep(vars["uuid"], vars["status"], middleware.AdminIDFromContext(r.Context()), req)
+		if err != nil {
+			if errors.Is(err, utils.ErrReasonRequired) {
+				http.Error(w, "Reason is required when rejecting an approval step", http.StatusBadRequest)
+				return
+			}
+			if errors.Is(err, utils.ErrTransactionType) {
+				http.Error(w, "Only loan and inquiry transactions have approval chains", http.StatusBadRequest)
+				return
+			}
+			if errors.Is(err, utils.ErrInvalidStatus) {
+				http.Error(w, "Invalid status, use approved or rejected", http.StatusBadRequest)
+				return
+			}
+			if errors.Is(err, utils.ErrApproverRoleRequired) || errors.Is(err, utils.ErrApproverAlreadyDecided) {
+				http.Error(w, err.Error(), http.StatusForbidden)
+				return
+			}
+			if errors.Is(err, utils.ErrTransactionNotFound) {
+				http.Error(w, "Transaction not found", http.StatusNotFound)
+				return
+			}
+			if errors.Is(err, utils.ErrItemNotFound) {
+				http.Error(w, "Item not found", http.StatusNotFound)
+				return
+			}
+			if errors.Is(err, utils.ErrApprovalStepNotFound) || errors.Is(err, utils.ErrInvalidTransition) {
+				http.Error(w, err.Error(), http.StatusConflict)
+				return
+			}
+			if errors.Is(err, utils.ErrInsufficientQuantity) {
+				http.Error(w, "Insufficient item quantity", http.StatusConflict)
+				return
+			}
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(approvals); err != nil {
+			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+			return
+		}
+	}))).Methods("PATCH")
+
+	r.Handle("/api/transaction/{uuid}/item-matches", middleware.AuthMiddleware(jwtUtils, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		uuid := mux.Vars(r)["uuid"]
+		matches, err := transactionService.GetInsertionItemMatches(uuid)
+		if err != nil {
+			if errors.Is(err, utils.ErrTransactionType) {
+				http.Error(w, "Only insertion transactions are matched to items", http.StatusBadRequest)
+				return
+			}
+			if errors.Is(err, utils.ErrTransactionNotFound) {
+				http.Error(w, "Transaction not found", http.StatusNotFound)
+				return
+			}
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(matches); err != nil {
+			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+			return
+		}
+	}))).Methods("GET")
+
+	r.HandleFunc("/api/transaction/{uuid}/track", func(w http.ResponseWriter, r *http.Request) {
+		uuid := mux.Vars(r)["uuid"]
+
+		// Query strings end up in access logs, so the token is only read
+		// from its header.
+		token := r.Header.Get("X-Tracking-Token")
+
+		tracking, err := transactionService.TrackTransaction(uuid, token)
+		if err != nil {
+			if errors.Is(err, utils.ErrTrackingTokenRequired) {
+				http.Error(w, "Tracking token is required", http.StatusUnauthorized)
+				return
+			}
+			if errors.Is(err, utils.ErrTransactionType) {
+				http.Error(w, "Invalid transaction type", http.StatusBadRequest)
+				return
+			}
+			if errors.Is(err, utils.ErrTransactionNotFound) {
+				http.Error(w, "Transaction not found", http.StatusNotFound)
+				return
+			}
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(tracking); err != nil {
+			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+			return
+		}
+	}).Methods("GET")
+
+	r.HandleFunc("/api/transaction/{uuid}/cancel", func(w http.ResponseWriter, r *http.Request) {
+		uuid := mux.Vars(r)["uuid"]
+
+		token := r.Header.Get("X-Tracking-Token")
+
+		var req model.CancelTransactionRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		response, err := transactionService.CancelTransaction(uuid, token, req)
+		if err != nil {
+			if errors.Is(err, utils.ErrTrackingTokenRequired) {
+				http.Error(w, "Tracking token is required", http.StatusUnauthorized)
+				return
+			}
+			if errors.Is(err, utils.ErrTransactionType) {
+				http.Error(w, "Invalid transaction type", http.StatusBadRequest)
+				return
+			}
+			if errors.Is(err, utils.ErrTransactionNotFound) {
+				http.Error(w, "Transaction not found", http.StatusNotFound)
+				return
+			}
+			if errors.Is(err, utils.ErrInvalidTransition) {
+				http.Error(w, err.Error(), http.StatusConflict)
+				return
+			}
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+			return
+		}
+	}).Methods("POST")
+
+	r.Handle("/api/transaction/{uuid}/history", middleware.AuthMiddleware(jwtUtils, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		uuid := mux.Vars(r)["uuid"]
+		history, err := transactionService.GetTransactionHistory(uuid)
+		if err != nil {
+			if errors.Is(err, utils.ErrTransactionType) {
+				http.Error(w, "Invalid transaction type", http.StatusBadRequest)
+				return
+			}
+			if errors.Is(err, utils.ErrTransactionNotFound) {
+				http.Error(w, "Transaction not found", http.StatusNotFound)
+				return
+			}
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(history); err != nil {
+			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+			return
+		}
+	}))).Methods("GET")
+
+	r.Handle("/api/transaction/{uuid}", middleware.AuthMiddleware(jwtUtils, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		uuid := mux.Vars(r)["uuid"]
+
+		var req model.DeleteTransactionRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		response, err := transactionService.DeleteTransaction(uuid, middleware.AdminIDFromContext(r.Context()), req)
+		if err != nil {
+			if errors.Is(err, utils.ErrTransactionType) {
+				http.Error(w, "Invalid transaction type", http.StatusBadRequest)
+				return
+			}
+			if errors.Is(err, utils.ErrTransactionNotFound) {
+				http.Error(w, "Transaction not found", http.StatusNotFound)
+				return
+			}
+			if errors.Is(err, utils.ErrTransactionHoldsStock) {
+				http.Error(w, "Transaction still holds reserved or loaned stock, close it before moving it to trash", http.StatusConflict)
+				return
+			}
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+			return
+		}
+	}))).Methods("DELETE")
+
+	r.Handle("/api/transaction/{uuid}/restore", middleware.AuthMiddleware(jwtUtils, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		uuid := mux.Vars(r)["uuid"]
+		response, err := transactionService.RestoreTransaction(uuid, middleware.AdminIDFromContext(r.Context()))
+		if err != nil {
+			if errors.Is(err, utils.ErrTransactionType) {
+				http.Error(w, "Invalid transaction type", http.StatusBadRequest)
+				return
+			}
+			if errors.Is(err, utils.ErrTransactionNotFound) {
+				http.Error(w, "Transaction not found in trash", http.StatusNotFound)
+				return
+			}
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+			return
+		}
+	}))).Methods("POST")
+
+	r.Handle("/api/transaction/{uuid}/purge", middleware.AuthMiddleware(jwtUtils, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		uuid := mux.Vars(r)["uuid"]
+		response, err := transactionService.PurgeTransaction(uuid, middleware.AdminIDFromContext(r.Context()))
+		if err != nil {
+			if errors.Is(err, utils.ErrTransactionType) {
+				http.Error(w, "Invalid transaction type", http.StatusBadRequest)
+				return
+			}
+			if errors.Is(err, utils.ErrTransactionNotFound) {
+				http.Error(w, "Transaction not found in trash", http.StatusNotFound)
+				return
+			}
+			if errors.Is(err, utils.ErrTransactionHoldsStock) {
+				http.Error(w, "Transaction still holds reserved or loaned stock, restore and close it before purging", http.StatusConflict)
+				return
+			}
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+			return
+		}
+	}))).Methods("DELETE")
+
+	r.HandleFunc("/api/transactions/export", func(w http.ResponseWriter, r *http.Request) {
+		startTimeParam := r.URL.Query().Get("from")
+		endTimeParam := r.URL.Query().Get("to")
+
+		var startTime, endTime time.Time
+    	var err error
+
+		if startTimeParam == "" {
+        // Default to a reasonable past time, e.g., 1 year ago
+			startTime = time.Now().AddDate(-1, 0, 0)
+		} else {
+			startTime, err = time.Parse(time.RFC3339, startTimeParam)
+			if err != nil {
+				http.Error(w, "Invalid start time format. Use RFC3339 (e.g., 2024-12-05T00:00:00Z)", http.StatusBadRequest)
+				return
+			}
+		}
+
+		if endTimeParam == "" {
+			endTime = time.Now()
+		} else {
+			endTime, err = time.Parse(time.RFC3339, endTimeParam)
+			if err != nil {
+				http.Error(w, "Invalid end time format. Use RFC3339 (e.g., 2024-12-05T23:59:59Z)", http.StatusBadRequest)
+				return
+			}
+    	}
+
+		includeDeleted, _ := strconv.ParseBool(r.URL.Query().Get("include_deleted"))
+
+		transactions, err := transactionService.ExportTransactions(startTime, endTime, includeDeleted)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Disposition", "attachment; filename=combined_transactions.csv")
+		w.Header().Set("Content-Type", "text/csv")
+
+		writer := csv.NewWriter(w)
+		defer writer.Flush()
+
+		header := []string{
+			"TransactionType", "ID", "UUID", "EmployeeName", "EmployeeDepartment", "EmployeePosition",
+			"CategoryName", "ItemName", "Quantity", "Status", "Notes", "Time", "ItemID",
+			"LoanTime", "ReturnTime", "CompletedTime", "ReturnedTime", "StatusReason", "AdminNote", "Image",
+			"DestinationStorageName", "DestinationCategoryName", "DestinationShelf", "DestinationItemID",
+		}
+		if err := writer.Write(header); err != nil {
+			http.Error(w, "Failed to write CSV header", http.StatusInternalServerError)
+			return
+		}
+
+		for _, t := range transactions {
+			row := []string{
+				t.TransactionType,
+				fmt.Sprintf("%d", t.ID),
+				t.UUID,
+				t.EmployeeName,
+				t.EmployeeDepartment,
+				t.EmployeePosition,
+				t.CategoryName.String,
+				t.ItemName.String,
 				fmt.Sprintf("%d", t.Quantity.Int32),
 				t.Status,
 				t.Notes.String,
@@ -317,7 +1099,13 @@ func TransactionRoutes(r *mux.Router, transactionService *service.TransactionSer
 				t.ReturnTime.Time.Format(time.RFC3339),
 				t.CompletedTime.Time.Format(time.RFC3339),
 				t.ReturnedTime.Time.Format(time.RFC3339),
+				t.StatusReason.String,
+				t.AdminNote.String,
 				t.Image.String,
+				t.DestinationStorageName.String,
+				t.DestinationCategoryName.String,
+				t.DestinationShelf.String,
+				fmt.Sprintf("%d", t.DestinationItemID.Int32),
 			}
 			if err := writer.Write(row); err != nil {
 				http.Error(w, "Failed to write CSV row", http.StatusInternalServerError)
@@ -326,3 +1114,62 @@ func TransactionRoutes(r *mux.Router, transactionService *service.TransactionSer
 		}
 	}).Methods("GET")
 }
+
+// parseTransactionFilter reads the filter of the transaction listing from the
+// query string. Statuses can be repeated or comma separated.
+func parseTransactionFilter(r *http.Request) (model.TransactionFilter, error) {
+	query := r.URL.Query()
+	filter := model.TransactionFilter{
+		Type:         strings.ToLower(query.Get("type")),
+		EmployeeName: strings.TrimSpace(query.Get("employee")),
+		Department:   strings.TrimSpace(query.Get("department")),
+		Search:       strings.TrimSpace(query.Get("q")),
+		Sort:         strings.ToLower(query.Get("sort")),
+		Order:        strings.ToLower(query.Get("order")),
+	}
+
+	for _, value := range query["status"] {
+		for _, status := range strings.Split(value, ",") {
+			if status = strings.ToLower(strings.TrimSpace(status)); status != "" {
+				filter.Statuses = append(filter.Statuses, status)
+			}
+		}
+	}
+
+	ids := map[string]**uint{
+		"employee_id": &filter.EmployeeID,
+		"item_id":     &filter.ItemID,
+		"category_id": &filter.CategoryID,
+		"storage_id":  &filter.StorageID,
+	}
+	for name, target := range ids {
+		value := query.Get(name)
+		if value == "" {
+			continue
+		}
+		id, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			return filter, fmt.Errorf("invalid %s", name)
+		}
+		parsed := uint(id)
+		*target = &parsed
+	}
+
+	times := map[string]**time.Time{
+		"from": &filter.From,
+		"to":   &filter.To,
+	}
+	for name, target := range times {
+		value := query.Get(name)
+		if value == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return filter, fmt.Errorf("invalid %s time format. Use RFC3339 (e.g., 2024-12-05T00:00:00Z)", name)
+		}
+		*target = &parsed
+	}
+
+	return filter, nil
+}
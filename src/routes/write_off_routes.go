@@ -0,0 +1,120 @@
+package routes
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"gtihub.com/raditsoic/telkom-storage-ms/src/middleware"
+	"gtihub.com/raditsoic/telkom-storage-ms/src/model"
+	"gtihub.com/raditsoic/telkom-storage-ms/src/service"
+	"gtihub.com/raditsoic/telkom-storage-ms/src/utils"
+)
+
+func WriteOffRoutes(r *mux.Router, writeOffService *service.WriteOffService, jwtUtils *utils.JWTUtils) {
+	r.Handle("/api/write-offs", middleware.AuthMiddleware(jwtUtils, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		if page < 1 {
+			page = 1
+		}
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		if limit < 1 {
+			limit = 10
+		}
+
+		filter, err := parseWriteOffFilter(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		writeOffs, err := writeOffService.GetWriteOffs(filter, page, limit)
+		if err != nil {
+			writeWriteOffError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(writeOffs); err != nil {
+			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+			return
+		}
+	}))).Methods("GET")
+
+	// Sums the write-offs per employee, or per department with
+	// group_by=department.
+	r.Handle("/api/write-offs/liability", middleware.AuthMiddleware(jwtUtils, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		filter, err := parseWriteOffFilter(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		report, err := writeOffService.GetLiabilityReport(r.URL.Query().Get("group_by"), filter)
+		if err != nil {
+			writeWriteOffError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(report); err != nil {
+			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+			return
+		}
+	}))).Methods("GET")
+}
+
+// parseWriteOffFilter reads the filter of the write-off listing and the
+// liability report from the query string.
+func parseWriteOffFilter(r *http.Request) (model.WriteOffFilter, error) {
+	query := r.URL.Query()
+	filter := model.WriteOffFilter{
+		Department: strings.TrimSpace(query.Get("department")),
+		Condition:  strings.ToLower(strings.TrimSpace(query.Get("condition"))),
+	}
+
+	if value := query.Get("employee_id"); value != "" {
+		id, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			return filter, fmt.Errorf("invalid employee_id")
+		}
+		parsed := uint(id)
+		filter.EmployeeID = &parsed
+	}
+
+	times := map[string]**time.Time{
+		"from": &filter.From,
+		"to":   &filter.To,
+	}
+	for name, target := range times {
+		value := query.Get(name)
+		if value == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return filter, fmt.Errorf("invalid %s time format. Use RFC3339 (e.g., 2024-12-05T00:00:00Z)", name)
+		}
+		*target = &parsed
+	}
+
+	return filter, nil
+}
+
+// writeWriteOffError maps the errors of a write-off report to their HTTP
+// status.
+func writeWriteOffError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, utils.ErrInvalidReturnCondition):
+		http.Error(w, "Invalid condition, use damaged or lost", http.StatusBadRequest)
+	case errors.Is(err, utils.ErrInvalidLiabilityGroup):
+		http.Error(w, "Invalid group_by, use employee or department", http.StatusBadRequest)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
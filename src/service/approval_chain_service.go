@@ -0,0 +1,297 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gtihub.com/raditsoic/telkom-storage-ms/src/database/repository"
+	"gtihub.com/raditsoic/telkom-storage-ms/src/model"
+	"gtihub.com/raditsoic/telkom-storage-ms/src/utils"
+)
+
+// Decisions of a transaction approval step.
+const (
+	ApprovalStepPending  = "pending"
+	ApprovalStepApproved = "approved"
+	ApprovalStepRejected = "rejected"
+	ApprovalStepSkipped  = "skipped"
+)
+
+type ApprovalChainService struct {
+	chainRepository repository.ApprovalChainRepository
+}
+
+func NewApprovalChainService(chain repository.ApprovalChainRepository) *ApprovalChainService {
+	return &ApprovalChainService{chainRepository: chain}
+}
+
+func parseApprovalChainID(idStr string) (uint, error) {
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		return 0, utils.ErrInvalidID
+	}
+
+	return uint(id), nil
+}
+
+func normalizeApproverRole(role string) string {
+	return strings.ToLower(strings.TrimSpace(role))
+}
+
+// applyApprovalChainRequest validates a create or update request and copies
+// it onto a chain. A chain needs a category or quantity threshold so that it
+// does not hold up every request by accident.
+func applyApprovalChainRequest(chain *model.ApprovalChain, req model.ApprovalChainRequest) error {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return fmt.Errorf("%w: name is required", utils.ErrInvalidApprovalChain)
+	}
+
+	transactionType := strings.ToLower(strings.TrimSpace(req.TransactionType))
+	if transactionType != "" && transactionType != "loan" && transactionType != "inquiry" {
+		return fmt.Errorf("%w: transaction_type must be loan or inquiry", utils.ErrInvalidApprovalChain)
+	}
+	if req.MinQuantity != nil && *req.MinQuantity <= 0 {
+		return fmt.Errorf("%w: min_quantity must be greater than 0", utils.ErrInvalidApprovalChain)
+	}
+	if req.CategoryID == nil && req.MinQuantity == nil {
+		return fmt.Errorf("%w: set category_id, min_quantity or both", utils.ErrInvalidApprovalChain)
+	}
+	if len(req.Roles) == 0 {
+		return fmt.Errorf("%w: at least one role is required", utils.ErrInvalidApprovalChain)
+	}
+
+	steps := make([]model.ApprovalChainStep, 0, len(req.Roles))
+	for i, role := range req.Roles {
+		role = normalizeApproverRole(role)
+		if role == "" {
+			return fmt.Errorf("%w: role %d is empty", utils.ErrInvalidApprovalChain, i+1)
+		}
+		steps = append(steps, model.ApprovalChainStep{Position: i + 1, Role: role})
+	}
+
+	chain.Name = name
+	chain.TransactionType = transactionType
+	chain.CategoryID = req.CategoryID
+	chain.MinQuantity = req.MinQuantity
+	chain.Priority = req.Priority
+	chain.Active = req.Active == nil || *req.Active
+	chain.Steps = steps
+
+	return nil
+}
+
+func (service *ApprovalChainService) CreateApprovalChain(req model.ApprovalChainRequest, adminID *uint) (*model.ApprovalChainResponse, error) {
+	chain := &model.ApprovalChain{CreatedBy: adminID, CreatedTime: time.Now()}
+	if err := applyApprovalChainRequest(chain, req); err != nil {
+		return nil, err
+	}
+
+	if err := service.chainRepository.CreateApprovalChain(chain); err != nil {
+		return nil, err
+	}
+
+	return &model.ApprovalChainResponse{
+		Message: "Approval chain created successfully",
+		ID:      strconv.FormatUint(uint64(chain.ID), 10),
+		Chain:   *chain,
+	}, nil
+}
+
+func (service *ApprovalChainService) GetApprovalChains() ([]model.ApprovalChain, error) {
+	return service.chainRepository.GetApprovalChains(false)
+}
+
+func (service *ApprovalChainService) GetApprovalChain(idStr string) (*model.ApprovalChain, error) {
+	id, err := parseApprovalChainID(idStr)
+	if err != nil {
+		return nil, err
+	}
+
+	chain, err := service.chainRepository.GetApprovalChainByID(id)
+	if err != nil {
+		return nil, utils.ErrApprovalChainNotFound
+	}
+
+	return chain, nil
+}
+
+// UpdateApprovalChain changes a chain for requests created from now on.
+// Requests already waiting keep the steps they were created with.
+func (service *ApprovalChainService) UpdateApprovalChain(idStr string, req model.ApprovalChainRequest) (*model.ApprovalChainResponse, error) {
+	chain, err := service.GetApprovalChain(idStr)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := applyApprovalChainRequest(chain, req); err != nil {
+		return nil, err
+	}
+
+	if err := service.chainRepository.UpdateApprovalChain(chain); err != nil {
+		return nil, err
+	}
+
+	return &model.ApprovalChainResponse{
+		Message: "Approval chain updated successfully",
+		ID:      idStr,
+		Chain:   *chain,
+	}, nil
+}
+
+func (service *ApprovalChainService) DeleteApprovalChain(idStr string) (*model.DeleteApprovalChainResponse, error) {
+	chain, err := service.GetApprovalChain(idStr)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := service.chainRepository.DeleteApprovalChain(chain.ID); err != nil {
+		return nil, err
+	}
+
+	return &model.DeleteApprovalChainResponse{
+		Message: "Approval chain deleted successfully",
+		ID:      idStr,
+	}, nil
+}
+
+func (service *ApprovalChainService) GetAdminRoles(adminIDStr string) ([]model.AdminRole, error) {
+	adminID, err := parseApprovalChainID(adminIDStr)
+	if err != nil {
+		return nil, err
+	}
+
+	return service.chainRepository.GetAdminRoles(adminID)
+}
+
+// SetAdminRoles replaces the approver roles of an admin.
+func (service *ApprovalChainService) SetAdminRoles(adminIDStr string, req model.AdminRolesRequest) ([]model.AdminRole, error) {
+	adminID, err := parseApprovalChainID(adminIDStr)
+	if err != nil {
+		return nil, err
+	}
+
+	roles := make([]model.AdminRole, 0, len(req.Roles))
+	seen := map[string]bool{}
+	for _, role := range req.Roles {
+		name := normalizeApproverRole(role.Role)
+		if name == "" {
+			return nil, fmt.Errorf("%w: role is required", utils.ErrInvalidApprovalChain)
+		}
+		department := strings.TrimSpace(role.Department)
+		key := name + "\x00" + strings.ToLower(department)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		roles = append(roles, model.AdminRole{AdminID: adminID, Role: name, Department: department})
+	}
+
+	if err := service.chainRepository.SetAdminRoles(adminID, roles); err != nil {
+		if errors.Is(err, gorm.ErrForeignKeyViolated) {
+			return nil, utils.ErrAdminNotFound
+		}
+		return nil, err
+	}
+
+	return roles, nil
+}
+
+// matchApprovalChain returns the first chain that applies to a request, or
+// nil when it needs no more than a single admin approval.
+func matchApprovalChain(chains []model.ApprovalChain, transactionType string, lines []model.TransactionLine, items []*model.Item) *model.ApprovalChain {
+	total := 0
+	for _, line := range lines {
+		total += line.Quantity
+	}
+
+	for i := range chains {
+		chain := &chains[i]
+		if chain.TransactionType != "" && chain.TransactionType != transactionType {
+			continue
+		}
+		if chain.MinQuantity != nil && total < *chain.MinQuantity {
+			continue
+		}
+		if chain.CategoryID != nil {
+			found := false
+			for _, item := range items {
+				if item.CategoryID == *chain.CategoryID {
+					found = true
+					break
+				}
+			}
+			if !found {
+				continue
+			}
+		}
+		if len(chain.Steps) == 0 {
+			continue
+		}
+
+		return chain
+	}
+
+	return nil
+}
+
+// startApprovalChain copies the steps of the chain applying to a freshly
+// created loan or inquiry onto it. It returns false when no chain applies.
+func startApprovalChain(chainRepository *repository.ApprovalChainRepository, logRepository *repository.TransactionRepository, transactionType string, transactionID uint, lines []model.TransactionLine, items []*model.Item) (bool, error) {
+	chains, err := chainRepository.GetApprovalChains(true)
+	if err != nil {
+		return false, err
+	}
+
+	chain := matchApprovalChain(chains, transactionType, lines, items)
+	if chain == nil {
+		return false, nil
+	}
+
+	approvals := make([]model.TransactionApproval, 0, len(chain.Steps))
+	for _, step := range chain.Steps {
+		approvals = append(approvals, model.TransactionApproval{
+			TransactionType: transactionType,
+			TransactionID:   transactionID,
+			ChainID:         &chain.ID,
+			Position:        step.Position,
+			Role:            step.Role,
+			Status:          ApprovalStepPending,
+		})
+	}
+
+	return true, logRepository.CreateTransactionApprovals(approvals)
+}
+
+// checkApprovalChain blocks approving a loan or inquiry while steps of its
+// approval chain are still waiting for a decision.
+func checkApprovalChain(logRepository *repository.TransactionRepository, transactionType string, transactionID uint) error {
+	pending, err := logRepository.HasPendingApprovals(transactionType, transactionID)
+	if err != nil {
+		return err
+	}
+	if pending {
+		return utils.ErrApprovalStepsPending
+	}
+
+	return nil
+}
+
+// holdsApproverRole reports whether one of an admin's roles covers a step
+// for a request made by the given department.
+func holdsApproverRole(roles []model.AdminRole, role, department string) bool {
+	for _, held := range roles {
+		if held.Role != role {
+			continue
+		}
+		if held.Department == "" || strings.EqualFold(held.Department, strings.TrimSpace(department)) {
+			return true
+		}
+	}
+
+	return false
+}
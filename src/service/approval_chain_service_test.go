@@ -0,0 +1,97 @@
+package service
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"gtihub.com/raditsoic/telkom-storage-ms/src/model"
+	"gtihub.com/raditsoic/telkom-storage-ms/src/utils"
+)
+
+func TestMatchApprovalChain(t *testing.T) {
+	step := []model.ApprovalChainStep{{Position: 1, Role: "manager"}}
+	chains := []model.ApprovalChain{
+		{ID: 1, TransactionType: "loan", MinQuantity: intPtr(10), Steps: step},
+		{ID: 2, CategoryID: uintPtr(20), Steps: step},
+		{ID: 3, TransactionType: "inquiry", CategoryID: uintPtr(10), MinQuantity: intPtr(3), Steps: step},
+		{ID: 4, MinQuantity: intPtr(1)},
+	}
+	items := []*model.Item{{ID: 1, CategoryID: 10}, {ID: 2, CategoryID: 20}}
+
+	tests := []struct {
+		name            string
+		transactionType string
+		lines           []model.TransactionLine
+		items           []*model.Item
+		want            uint
+	}{
+		{"quantity threshold reached", "loan", []model.TransactionLine{{ItemID: 1, Quantity: 6}, {ItemID: 1, Quantity: 4}}, items[:1], 1},
+		{"quantity threshold missed", "loan", []model.TransactionLine{{ItemID: 1, Quantity: 9}}, items[:1], 0},
+		{"category on any line", "loan", []model.TransactionLine{{ItemID: 1, Quantity: 1}, {ItemID: 2, Quantity: 1}}, items, 2},
+		{"first matching chain wins", "loan", []model.TransactionLine{{ItemID: 2, Quantity: 10}}, items[1:], 1},
+		{"type and category and quantity", "inquiry", []model.TransactionLine{{ItemID: 1, Quantity: 3}}, items[:1], 3},
+		{"other type skipped", "inquiry", []model.TransactionLine{{ItemID: 1, Quantity: 20}}, items[:1], 3},
+		{"chain without steps never applies", "inquiry", []model.TransactionLine{{ItemID: 1, Quantity: 2}}, items[:1], 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chain := matchApprovalChain(chains, tt.transactionType, tt.lines, tt.items)
+			var got uint
+			if chain != nil {
+				got = chain.ID
+			}
+			if got != tt.want {
+				t.Fatalf("matchApprovalChain() = chain %d, want chain %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyApprovalChainRequestStepOrder(t *testing.T) {
+	var chain model.ApprovalChain
+	err := applyApprovalChainRequest(&chain, model.ApprovalChainRequest{
+		Name:        "Large loans",
+		MinQuantity: intPtr(10),
+		Roles:       []string{" Manager", "finance ", "DIRECTOR"},
+	})
+	if err != nil {
+		t.Fatalf("applyApprovalChainRequest() = %v", err)
+	}
+
+	want := []model.ApprovalChainStep{
+		{Position: 1, Role: "manager"},
+		{Position: 2, Role: "finance"},
+		{Position: 3, Role: "director"},
+	}
+	if !reflect.DeepEqual(chain.Steps, want) {
+		t.Fatalf("steps = %+v, want %+v", chain.Steps, want)
+	}
+	if !chain.Active {
+		t.Fatalf("chain without active flag should be active")
+	}
+}
+
+func TestApplyApprovalChainRequestValidation(t *testing.T) {
+	tests := []struct {
+		name string
+		req  model.ApprovalChainRequest
+	}{
+		{"missing name", model.ApprovalChainRequest{MinQuantity: intPtr(1), Roles: []string{"manager"}}},
+		{"unknown type", model.ApprovalChainRequest{Name: "c", TransactionType: "insert", MinQuantity: intPtr(1), Roles: []string{"manager"}}},
+		{"no threshold", model.ApprovalChainRequest{Name: "c", Roles: []string{"manager"}}},
+		{"zero min quantity", model.ApprovalChainRequest{Name: "c", MinQuantity: intPtr(0), Roles: []string{"manager"}}},
+		{"no roles", model.ApprovalChainRequest{Name: "c", MinQuantity: intPtr(1)}},
+		{"blank role", model.ApprovalChainRequest{Name: "c", MinQuantity: intPtr(1), Roles: []string{"manager", " "}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var chain model.ApprovalChain
+			if err := applyApprovalChainRequest(&chain, tt.req); !errors.Is(err, utils.ErrInvalidApprovalChain) {
+				t.Fatalf("applyApprovalChainRequest() = %v, want %v", err, utils.ErrInvalidApprovalChain)
+			}
+		})
+	}
+}
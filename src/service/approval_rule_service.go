@@ -0,0 +1,314 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gtihub.com/raditsoic/telkom-storage-ms/src/database/repository"
+	"gtihub.com/raditsoic/telkom-storage-ms/src/model"
+	"gtihub.com/raditsoic/telkom-storage-ms/src/utils"
+)
+
+type ApprovalRuleService struct {
+	ruleRepository     repository.ApprovalRuleRepository
+	itemRepository     repository.ItemRepository
+	employeeRepository repository.EmployeeRepository
+}
+
+func NewApprovalRuleService(rule repository.ApprovalRuleRepository, item repository.ItemRepository, employee repository.EmployeeRepository) *ApprovalRuleService {
+	return &ApprovalRuleService{ruleRepository: rule, itemRepository: item, employeeRepository: employee}
+}
+
+func parseApprovalRuleID(idStr string) (uint, error) {
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		return 0, utils.ErrInvalidID
+	}
+
+	return uint(id), nil
+}
+
+// applyApprovalRuleRequest validates a create or update request and copies
+// it onto a rule. A rule needs at least one condition besides the stock
+// check, so that it cannot approve everything by accident.
+func applyApprovalRuleRequest(rule *model.ApprovalRule, req model.ApprovalRuleRequest) error {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return fmt.Errorf("%w: name is required", utils.ErrInvalidApprovalRule)
+	}
+
+	transactionType := strings.ToLower(strings.TrimSpace(req.TransactionType))
+	if transactionType != "" && transactionType != "loan" && transactionType != "inquiry" {
+		return fmt.Errorf("%w: transaction_type must be loan or inquiry", utils.ErrInvalidApprovalRule)
+	}
+	if req.MaxQuantity != nil && *req.MaxQuantity <= 0 {
+		return fmt.Errorf("%w: max_quantity must be greater than 0", utils.ErrInvalidApprovalRule)
+	}
+	if req.MinRemainingStock != nil && *req.MinRemainingStock < 0 {
+		return fmt.Errorf("%w: min_remaining_stock cannot be negative", utils.ErrInvalidApprovalRule)
+	}
+
+	department := strings.TrimSpace(req.Department)
+	if req.CategoryID == nil && req.ItemID == nil && req.MaxQuantity == nil && department == "" {
+		return fmt.Errorf("%w: set at least one of category_id, item_id, max_quantity or department", utils.ErrInvalidApprovalRule)
+	}
+
+	rule.Name = name
+	rule.TransactionType = transactionType
+	rule.CategoryID = req.CategoryID
+	rule.ItemID = req.ItemID
+	rule.MaxQuantity = req.MaxQuantity
+	rule.Department = department
+	rule.MinRemainingStock = req.MinRemainingStock
+	rule.Priority = req.Priority
+	rule.Active = req.Active == nil || *req.Active
+
+	return nil
+}
+
+func (service *ApprovalRuleService) CreateApprovalRule(req model.ApprovalRuleRequest, adminID *uint) (*model.ApprovalRuleResponse, error) {
+	rule := &model.ApprovalRule{CreatedBy: adminID, CreatedTime: time.Now()}
+	if err := applyApprovalRuleRequest(rule, req); err != nil {
+		return nil, err
+	}
+
+	if err := service.ruleRepository.CreateApprovalRule(rule); err != nil {
+		return nil, err
+	}
+
+	return &model.ApprovalRuleResponse{
+		Message: "Approval rule created successfully",
+		ID:      strconv.FormatUint(uint64(rule.ID), 10),
+		Rule:    *rule,
+	}, nil
+}
+
+func (service *ApprovalRuleService) GetApprovalRules() ([]model.ApprovalRule, error) {
+	return service.ruleRepository.GetApprovalRules(false)
+}
+
+func (service *ApprovalRuleService) GetApprovalRule(idStr string) (*model.ApprovalRule, error) {
+	id, err := parseApprovalRuleID(idStr)
+	if err != nil {
+		return nil, err
+	}
+
+	rule, err := service.ruleRepository.GetApprovalRuleByID(id)
+	if err != nil {
+		return nil, utils.ErrApprovalRuleNotFound
+	}
+
+	return rule, nil
+}
+
+func (service *ApprovalRuleService) UpdateApprovalRule(idStr string, req model.ApprovalRuleRequest) (*model.ApprovalRuleResponse, error) {
+	rule, err := service.GetApprovalRule(idStr)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := applyApprovalRuleRequest(rule, req); err != nil {
+		return nil, err
+	}
+
+	if err := service.ruleRepository.UpdateApprovalRule(rule); err != nil {
+		return nil, err
+	}
+
+	return &model.ApprovalRuleResponse{
+		Message: "Approval rule updated successfully",
+		ID:      idStr,
+		Rule:    *rule,
+	}, nil
+}
+
+func (service *ApprovalRuleService) DeleteApprovalRule(idStr string) (*model.DeleteApprovalRuleResponse, error) {
+	rule, err := service.GetApprovalRule(idStr)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := service.ruleRepository.DeleteApprovalRule(rule.ID); err != nil {
+		return nil, err
+	}
+
+	return &model.DeleteApprovalRuleResponse{
+		Message: "Approval rule deleted successfully",
+		ID:      idStr,
+	}, nil
+}
+
+// DryRunApprovalRules tells which active rule, if any, would approve a
+// request, and why each of the others would not. Nothing is created.
+func (service *ApprovalRuleService) DryRunApprovalRules(req model.ApprovalRuleDryRunRequest) (*model.ApprovalRuleDryRunResponse, error) {
+	transactionType := strings.ToLower(req.TransactionType)
+	if transactionType != "loan" && transactionType != "inquiry" {
+		return nil, utils.ErrTransactionType
+	}
+
+	department := req.EmployeeDepartment
+	if req.EmployeeID != nil {
+		employee, err := service.employeeRepository.GetEmployeeByID(*req.EmployeeID)
+		if err != nil {
+			return nil, utils.ErrEmployeeNotFound
+		}
+		department = employee.Department
+	}
+
+	lines := req.Lines
+	if len(lines) == 0 {
+		if req.ItemID == nil {
+			return nil, utils.ErrItemNotFound
+		}
+		lines = []model.TransactionLine{{ItemID: *req.ItemID, Quantity: req.Quantity}}
+	}
+
+	items := make(map[uint]*model.Item, len(lines))
+	for _, line := range lines {
+		if line.Quantity <= 0 {
+			return nil, utils.ErrInvalidQuantity
+		}
+		if _, ok := items[line.ItemID]; ok {
+			continue
+		}
+		item, err := service.itemRepository.GetItemByID(strconv.FormatUint(uint64(line.ItemID), 10))
+		if err != nil {
+			return nil, fmt.Errorf("item with ID %d not found: %w", line.ItemID, utils.ErrItemNotFound)
+		}
+		items[line.ItemID] = item
+	}
+
+	rules, err := service.ruleRepository.GetApprovalRules(true)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &model.ApprovalRuleDryRunResponse{Evaluation: make([]model.ApprovalRuleDecision, 0, len(rules))}
+	for i := range rules {
+		failures := evaluateApprovalRule(rules[i], transactionType, department, lines, items)
+		response.Evaluation = append(response.Evaluation, model.ApprovalRuleDecision{
+			RuleID:   rules[i].ID,
+			Name:     rules[i].Name,
+			Matched:  len(failures) == 0,
+			Failures: failures,
+		})
+		if len(failures) == 0 && response.Rule == nil {
+			response.Matched = true
+			response.Rule = &rules[i]
+		}
+	}
+
+	return response, nil
+}
+
+// evaluateApprovalRule lists the conditions of a rule that a request fails.
+// The request matches when there are none. Whatever the rule says, the
+// requested items must be available for the request to be approved.
+func evaluateApprovalRule(rule model.ApprovalRule, transactionType, department string, lines []model.TransactionLine, items map[uint]*model.Item) []string {
+	var failures []string
+
+	if rule.TransactionType != "" && rule.TransactionType != transactionType {
+		failures = append(failures, fmt.Sprintf("applies to %s requests only", rule.TransactionType))
+	}
+	if rule.Department != "" && !strings.EqualFold(rule.Department, strings.TrimSpace(department)) {
+		failures = append(failures, fmt.Sprintf("applies to department %s only", rule.Department))
+	}
+
+	total := 0
+	requested := make(map[uint]int, len(items))
+	for _, line := range lines {
+		total += line.Quantity
+		requested[line.ItemID] += line.Quantity
+
+		if rule.ItemID != nil && line.ItemID != *rule.ItemID {
+			failures = append(failures, fmt.Sprintf("item %d is not item %d", line.ItemID, *rule.ItemID))
+		}
+		if rule.CategoryID != nil && items[line.ItemID].CategoryID != *rule.CategoryID {
+			failures = append(failures, fmt.Sprintf("item %d is not in category %d", line.ItemID, *rule.CategoryID))
+		}
+	}
+
+	if rule.MaxQuantity != nil && total > *rule.MaxQuantity {
+		failures = append(failures, fmt.Sprintf("requests %d units, more than %d", total, *rule.MaxQuantity))
+	}
+
+	minRemaining := 0
+	if rule.MinRemainingStock != nil {
+		minRemaining = *rule.MinRemainingStock
+	}
+	itemIDs := make([]uint, 0, len(requested))
+	for itemID := range requested {
+		itemIDs = append(itemIDs, itemID)
+	}
+	sort.Slice(itemIDs, func(i, j int) bool { return itemIDs[i] < itemIDs[j] })
+	for _, itemID := range itemIDs {
+		item := items[itemID]
+		remaining := item.Quantity - item.Reserved - requested[itemID]
+		if remaining < minRemaining {
+			failures = append(failures, fmt.Sprintf("item %d would have %d available left, less than %d", itemID, remaining, minRemaining))
+		}
+	}
+
+	return failures
+}
+
+// autoApprove moves a freshly created loan or inquiry straight to approved
+// when an active rule matches it, reserving its stock as an admin approval
+// would. It returns the rule that fired, or nil when the request is left for
+// an admin.
+func autoApprove(ruleRepository *repository.ApprovalRuleRepository, logRepository *repository.TransactionRepository, itemRepository *repository.ItemRepository, transactionType string, transactionUUID uuid.UUID, department string, lines []model.TransactionLine) (*model.ApprovalRule, error) {
+	rules, err := ruleRepository.GetApprovalRules(true)
+	if err != nil {
+		return nil, err
+	}
+	if len(rules) == 0 {
+		return nil, nil
+	}
+
+	// Lock the items in a fixed order so the stock conditions are checked
+	// against the quantities the reservation is made from.
+	itemIDs := make([]uint, 0, len(lines))
+	for _, line := range lines {
+		itemIDs = append(itemIDs, line.ItemID)
+	}
+	sort.Slice(itemIDs, func(i, j int) bool { return itemIDs[i] < itemIDs[j] })
+	items := make(map[uint]*model.Item, len(itemIDs))
+	for _, itemID := range itemIDs {
+		if _, ok := items[itemID]; ok {
+			continue
+		}
+		item, err := itemRepository.GetItemByIDForUpdate(itemID)
+		if err != nil {
+			return nil, utils.ErrItemNotFound
+		}
+		items[itemID] = item
+	}
+
+	var rule *model.ApprovalRule
+	for i := range rules {
+		if len(evaluateApprovalRule(rules[i], transactionType, department, lines, items)) == 0 {
+			rule = &rules[i]
+			break
+		}
+	}
+	if rule == nil {
+		return nil, nil
+	}
+
+	for i := range lines {
+		if err := moveLine(logRepository, itemRepository, &lines[i], StatusApproved); err != nil {
+			return nil, err
+		}
+	}
+
+	comment := fmt.Sprintf("Auto-approved by rule %d (%s)", rule.ID, rule.Name)
+	if err := recordStatusChange(logRepository, transactionType, transactionUUID, nil, StatusPending, StatusApproved, nil, "", comment); err != nil {
+		return nil, err
+	}
+
+	return rule, nil
+}
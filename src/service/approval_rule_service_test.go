@@ -0,0 +1,102 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+
+	"gtihub.com/raditsoic/telkom-storage-ms/src/model"
+)
+
+func intPtr(v int) *int {
+	return &v
+}
+
+func TestEvaluateApprovalRule(t *testing.T) {
+	items := map[uint]*model.Item{
+		1: {ID: 1, CategoryID: 10, Quantity: 20, Reserved: 5},
+		2: {ID: 2, CategoryID: 20, Quantity: 3},
+	}
+
+	tests := []struct {
+		name            string
+		rule            model.ApprovalRule
+		transactionType string
+		department      string
+		lines           []model.TransactionLine
+		want            []string
+	}{
+		{
+			name:            "matches every condition",
+			rule:            model.ApprovalRule{TransactionType: "inquiry", CategoryID: uintPtr(10), MaxQuantity: intPtr(5), Department: "Finance"},
+			transactionType: "inquiry",
+			department:      "finance",
+			lines:           []model.TransactionLine{{ItemID: 1, Quantity: 5}},
+		},
+		{
+			name:            "other transaction type",
+			rule:            model.ApprovalRule{TransactionType: "loan", MaxQuantity: intPtr(10)},
+			transactionType: "inquiry",
+			lines:           []model.TransactionLine{{ItemID: 1, Quantity: 1}},
+			want:            []string{"applies to loan requests only"},
+		},
+		{
+			name:            "other department",
+			rule:            model.ApprovalRule{Department: "Finance"},
+			transactionType: "loan",
+			department:      "IT",
+			lines:           []model.TransactionLine{{ItemID: 1, Quantity: 1}},
+			want:            []string{"applies to department Finance only"},
+		},
+		{
+			name:            "other item",
+			rule:            model.ApprovalRule{ItemID: uintPtr(1)},
+			transactionType: "loan",
+			lines:           []model.TransactionLine{{ItemID: 2, Quantity: 1}},
+			want:            []string{"item 2 is not item 1"},
+		},
+		{
+			name:            "other category",
+			rule:            model.ApprovalRule{CategoryID: uintPtr(10)},
+			transactionType: "loan",
+			lines:           []model.TransactionLine{{ItemID: 1, Quantity: 1}, {ItemID: 2, Quantity: 1}},
+			want:            []string{"item 2 is not in category 10"},
+		},
+		{
+			name:            "total over max quantity",
+			rule:            model.ApprovalRule{MaxQuantity: intPtr(4)},
+			transactionType: "loan",
+			lines:           []model.TransactionLine{{ItemID: 1, Quantity: 3}, {ItemID: 1, Quantity: 2}},
+			want:            []string{"requests 5 units, more than 4"},
+		},
+		{
+			name:            "reserved units are not available",
+			rule:            model.ApprovalRule{MaxQuantity: intPtr(100)},
+			transactionType: "loan",
+			lines:           []model.TransactionLine{{ItemID: 1, Quantity: 16}},
+			want:            []string{"item 1 would have -1 available left, less than 0"},
+		},
+		{
+			name:            "min remaining stock",
+			rule:            model.ApprovalRule{MaxQuantity: intPtr(100), MinRemainingStock: intPtr(5)},
+			transactionType: "loan",
+			lines:           []model.TransactionLine{{ItemID: 1, Quantity: 10}, {ItemID: 2, Quantity: 1}},
+			want:            []string{"item 2 would have 2 available left, less than 5"},
+		},
+		{
+			name:            "several failures in order",
+			rule:            model.ApprovalRule{TransactionType: "inquiry", MaxQuantity: intPtr(1)},
+			transactionType: "loan",
+			lines:           []model.TransactionLine{{ItemID: 2, Quantity: 4}},
+			want:            []string{"applies to inquiry requests only", "requests 4 units, more than 1", "item 2 would have -1 available left, less than 0"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := evaluateApprovalRule(tt.rule, tt.transactionType, tt.department, tt.lines, items)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("evaluateApprovalRule() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
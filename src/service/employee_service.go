@@ -0,0 +1,242 @@
+package service
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gtihub.com/raditsoic/telkom-storage-ms/src/database/repository"
+	"gtihub.com/raditsoic/telkom-storage-ms/src/model"
+	"gtihub.com/raditsoic/telkom-storage-ms/src/utils"
+)
+
+// openLoanStatuses are the loan statuses shown as open on an employee's
+// page: requests still being decided and loans whose items are out.
+var openLoanStatuses = []string{StatusPending, StatusIncomplete, StatusApproved, StatusCompleted, StatusOverdue}
+
+type EmployeeService struct {
+	employeeRepository repository.EmployeeRepository
+}
+
+func NewEmployeeService(employee repository.EmployeeRepository) *EmployeeService {
+	return &EmployeeService{employeeRepository: employee}
+}
+
+// normalizeEmployeeName folds case and whitespace so that differently typed
+// names of the same person compare equal. The directory migration applies
+// the same rule in SQL.
+func normalizeEmployeeName(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
+
+func parseEmployeeID(idStr string) (uint, error) {
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		return 0, utils.ErrInvalidID
+	}
+
+	return uint(id), nil
+}
+
+// applyEmployeeRequest copies a create or update request onto an employee.
+func applyEmployeeRequest(employee *model.Employee, req model.EmployeeRequest) error {
+	name := strings.Join(strings.Fields(req.Name), " ")
+	if name == "" {
+		return utils.ErrEmployeeNameRequired
+	}
+
+	employee.Name = name
+	employee.NormalizedName = normalizeEmployeeName(name)
+	employee.Department = strings.TrimSpace(req.Department)
+	employee.Position = strings.TrimSpace(req.Position)
+	employee.IDNumber = nil
+	if idNumber := strings.TrimSpace(req.IDNumber); idNumber != "" {
+		employee.IDNumber = &idNumber
+	}
+
+	return nil
+}
+
+func (service *EmployeeService) CreateEmployee(req model.EmployeeRequest) (*model.EmployeeResponse, error) {
+	employee := &model.Employee{CreatedTime: time.Now()}
+	if err := applyEmployeeRequest(employee, req); err != nil {
+		return nil, err
+	}
+
+	if err := service.employeeRepository.CreateEmployee(employee); err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return nil, utils.ErrEmployeeIDNumberExists
+		}
+		return nil, err
+	}
+
+	return &model.EmployeeResponse{
+		Message:  "Employee created successfully",
+		ID:       strconv.FormatUint(uint64(employee.ID), 10),
+		Employee: *employee,
+	}, nil
+}
+
+func (service *EmployeeService) GetEmployees(search string, page, limit int) (*model.GetEmployeesResponse, error) {
+	employees, total, err := service.employeeRepository.GetEmployees(strings.TrimSpace(search), limit, (page-1)*limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.GetEmployeesResponse{
+		Employees: employees,
+		Total:     total,
+		Page:      page,
+		Limit:     limit,
+	}, nil
+}
+
+func (service *EmployeeService) GetEmployee(idStr string) (*model.Employee, error) {
+	id, err := parseEmployeeID(idStr)
+	if err != nil {
+		return nil, err
+	}
+
+	employee, err := service.employeeRepository.GetEmployeeByID(id)
+	if err != nil {
+		return nil, utils.ErrEmployeeNotFound
+	}
+
+	return employee, nil
+}
+
+// UpdateEmployee replaces the details of an employee. Transactions keep the
+// name and department they were made under.
+func (service *EmployeeService) UpdateEmployee(idStr string, req model.EmployeeRequest) (*model.EmployeeResponse, error) {
+	employee, err := service.GetEmployee(idStr)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := applyEmployeeRequest(employee, req); err != nil {
+		return nil, err
+	}
+
+	if err := service.employeeRepository.UpdateEmployee(employee); err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return nil, utils.ErrEmployeeIDNumberExists
+		}
+		return nil, err
+	}
+
+	return &model.EmployeeResponse{
+		Message:  "Employee updated successfully",
+		ID:       idStr,
+		Employee: *employee,
+	}, nil
+}
+
+// DeleteEmployee removes an employee from the directory. Their transactions
+// stay, unlinked.
+func (service *EmployeeService) DeleteEmployee(idStr string) (*model.DeleteEmployeeResponse, error) {
+	employee, err := service.GetEmployee(idStr)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := service.employeeRepository.DeleteEmployee(employee.ID); err != nil {
+		return nil, err
+	}
+
+	return &model.DeleteEmployeeResponse{
+		Message: "Employee deleted successfully",
+		ID:      idStr,
+	}, nil
+}
+
+// SuggestEmployees autocompletes employee names for the request forms.
+func (service *EmployeeService) SuggestEmployees(query string, limit int) ([]model.EmployeeSuggestion, error) {
+	suggestions := []model.EmployeeSuggestion{}
+
+	name := normalizeEmployeeName(query)
+	if name == "" {
+		return suggestions, nil
+	}
+
+	employees, err := service.employeeRepository.SearchEmployees(name, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, employee := range employees {
+		suggestions = append(suggestions, model.EmployeeSuggestion{
+			ID:         employee.ID,
+			Name:       employee.Name,
+			Department: employee.Department,
+			Position:   employee.Position,
+		})
+	}
+
+	return suggestions, nil
+}
+
+// GetEmployeeActivity shows the open loans, inquiries and total consumption
+// per item of an employee.
+func (service *EmployeeService) GetEmployeeActivity(idStr string) (*model.EmployeeActivityResponse, error) {
+	employee, err := service.GetEmployee(idStr)
+	if err != nil {
+		return nil, err
+	}
+
+	loans, err := service.employeeRepository.GetOpenLoans(employee.ID, openLoanStatuses)
+	if err != nil {
+		return nil, err
+	}
+
+	inquiries, err := service.employeeRepository.GetInquiries(employee.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	consumption, err := service.employeeRepository.GetConsumption(employee.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.EmployeeActivityResponse{
+		Employee:    *employee,
+		OpenLoans:   loans,
+		Inquiries:   inquiries,
+		Consumption: consumption,
+	}, nil
+}
+
+// resolveEmployee links a new transaction to the employee directory. An
+// explicit employee ID wins and its directory details replace the typed
+// ones. Otherwise the transaction is linked when exactly one employee has
+// the same normalized name, and left unlinked when none or several do.
+func (s *TransactionService) resolveEmployee(employeeID *uint, name, department, position *string) (*uint, error) {
+	if employeeID != nil {
+		employee, err := s.employeeRepository.GetEmployeeByID(*employeeID)
+		if err != nil {
+			return nil, utils.ErrEmployeeNotFound
+		}
+
+		*name = employee.Name
+		*department = employee.Department
+		*position = employee.Position
+		return &employee.ID, nil
+	}
+
+	normalized := normalizeEmployeeName(*name)
+	if normalized == "" {
+		return nil, nil
+	}
+
+	employees, err := s.employeeRepository.GetEmployeesByNormalizedName(normalized)
+	if err != nil {
+		return nil, err
+	}
+	if len(employees) != 1 {
+		return nil, nil
+	}
+
+	return &employees[0].ID, nil
+}
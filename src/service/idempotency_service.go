@@ -0,0 +1,252 @@
+package service
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"mime"
+	"os"
+	"time"
+
+	"gorm.io/gorm"
+	"gtihub.com/raditsoic/telkom-storage-ms/src/database/repository"
+	"gtihub.com/raditsoic/telkom-storage-ms/src/model"
+	"gtihub.com/raditsoic/telkom-storage-ms/src/utils"
+)
+
+// idempotencyKeyTTL is how long a stored response is replayed. A key reused
+// after that starts a new request.
+const idempotencyKeyTTL = 24 * time.Hour
+
+// secretResponseFields are secrets handed out once, such as the tracking
+// token of a new request. They are stored encrypted apart from the rest of
+// the response and put back when it is replayed.
+var secretResponseFields = []string{"tracking_token"}
+
+type IdempotencyService struct {
+	idempotencyRepository repository.IdempotencyRepository
+	secretCipher          cipher.AEAD
+}
+
+func NewIdempotencyService(idempotency repository.IdempotencyRepository) *IdempotencyService {
+	return &IdempotencyService{idempotencyRepository: idempotency, secretCipher: newSecretCipher()}
+}
+
+// newSecretCipher builds the cipher for stored response secrets from
+// IDEMPOTENCY_SECRET, or JWT_SECRET when it is not set. Without either, a
+// random key is used and responses stored before a restart cannot be
+// replayed.
+func newSecretCipher() cipher.AEAD {
+	secret := os.Getenv("IDEMPOTENCY_SECRET")
+	if secret == "" {
+		secret = os.Getenv("JWT_SECRET")
+	}
+
+	key := make([]byte, 32)
+	if secret != "" {
+		sum := sha256.Sum256([]byte(secret))
+		copy(key, sum[:])
+	} else {
+		log.Printf("IDEMPOTENCY_SECRET is not set, stored responses will not survive a restart")
+		if _, err := rand.Read(key); err != nil {
+			log.Fatalf("Could not generate idempotency secret: %v", err)
+		}
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		log.Fatalf("Could not create idempotency cipher: %v", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		log.Fatalf("Could not create idempotency cipher: %v", err)
+	}
+
+	return aead
+}
+
+// BeginIdempotentRequest claims a key of a client for a request. It returns
+// the stored response when the same request already finished, or nil when
+// the caller should run the request and then call CompleteIdempotentRequest.
+func (service *IdempotencyService) BeginIdempotentRequest(endpoint, client, key, requestHash string) (*model.IdempotencyKey, error) {
+	for attempt := 0; attempt < 2; attempt++ {
+		err := service.idempotencyRepository.CreateIdempotencyKey(&model.IdempotencyKey{
+			Key:         key,
+			Endpoint:    endpoint,
+			Client:      client,
+			RequestHash: requestHash,
+			CreatedTime: time.Now(),
+		})
+		if err == nil {
+			return nil, nil
+		}
+		if !errors.Is(err, gorm.ErrDuplicatedKey) {
+			return nil, err
+		}
+
+		existing, err := service.idempotencyRepository.GetIdempotencyKey(endpoint, client, key)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				// Released by a failed request in between, claim it again.
+				continue
+			}
+			return nil, err
+		}
+
+		if time.Since(existing.CreatedTime) > idempotencyKeyTTL {
+			if err := service.idempotencyRepository.DeleteIdempotencyKey(existing.ID); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if existing.RequestHash != requestHash {
+			return nil, utils.ErrIdempotencyKeyReused
+		}
+		if existing.StatusCode == 0 {
+			return nil, utils.ErrIdempotencyKeyInProgress
+		}
+
+		response, err := service.restoreSecrets(existing.Response, existing.Secrets)
+		if err != nil {
+			return nil, err
+		}
+		existing.Response = response
+
+		return existing, nil
+	}
+
+	return nil, utils.ErrIdempotencyKeyInProgress
+}
+
+// CompleteIdempotentRequest stores the response of a claimed request. Server
+// errors release the key instead, so the client can retry with it.
+func (service *IdempotencyService) CompleteIdempotentRequest(endpoint, client, key string, statusCode int, contentType string, body []byte) error {
+	record, err := service.idempotencyRepository.GetIdempotencyKey(endpoint, client, key)
+	if err != nil {
+		return err
+	}
+
+	if statusCode >= 500 {
+		return service.idempotencyRepository.DeleteIdempotencyKey(record.ID)
+	}
+
+	response, secrets, err := service.splitSecrets(contentType, body)
+	if err != nil {
+		return err
+	}
+
+	record.StatusCode = statusCode
+	record.ContentType = contentType
+	record.Response = response
+	record.Secrets = secrets
+	return service.idempotencyRepository.UpdateIdempotencyKey(record)
+}
+
+// splitSecrets takes the secret fields out of a JSON object response and
+// returns the rest of it along with the secrets, encrypted. Other responses
+// are stored as they are.
+func (service *IdempotencyService) splitSecrets(contentType string, body []byte) ([]byte, []byte, error) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil || mediaType != "application/json" {
+		return body, nil, nil
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return body, nil, nil
+	}
+
+	secrets := map[string]json.RawMessage{}
+	for _, field := range secretResponseFields {
+		if value, ok := fields[field]; ok {
+			secrets[field] = value
+			delete(fields, field)
+		}
+	}
+	if len(secrets) == 0 {
+		return body, nil, nil
+	}
+
+	response, err := json.Marshal(fields)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to store idempotent response: %w", err)
+	}
+	plaintext, err := json.Marshal(secrets)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to store idempotent response: %w", err)
+	}
+
+	nonce := make([]byte, service.secretCipher.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, nil, fmt.Errorf("failed to store idempotent response: %w", err)
+	}
+
+	return response, service.secretCipher.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+// restoreSecrets puts the secrets taken out by splitSecrets back into a
+// stored response.
+func (service *IdempotencyService) restoreSecrets(response, sealed []byte) ([]byte, error) {
+	if len(sealed) == 0 {
+		return response, nil
+	}
+
+	nonceSize := service.secretCipher.NonceSize()
+	if len(sealed) < nonceSize {
+		return nil, errors.New("failed to replay idempotent response: stored secrets are corrupt")
+	}
+	plaintext, err := service.secretCipher.Open(nil, sealed[:nonceSize], sealed[nonceSize:], nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to replay idempotent response: %w", err)
+	}
+
+	var fields, secrets map[string]json.RawMessage
+	if err := json.Unmarshal(response, &fields); err != nil {
+		return nil, fmt.Errorf("failed to replay idempotent response: %w", err)
+	}
+	if err := json.Unmarshal(plaintext, &secrets); err != nil {
+		return nil, fmt.Errorf("failed to replay idempotent response: %w", err)
+	}
+	for field, value := range secrets {
+		fields[field] = value
+	}
+
+	return json.Marshal(fields)
+}
+
+// ReleaseIdempotentRequest gives up a claimed key whose request did not
+// finish, so the client can retry with it.
+func (service *IdempotencyService) ReleaseIdempotentRequest(endpoint, client, key string) error {
+	return service.idempotencyRepository.DeleteInProgressIdempotencyKey(endpoint, client, key)
+}
+
+// PurgeExpiredIdempotencyKeys deletes the keys that are past their TTL and
+// would no longer be replayed, and returns how many were deleted.
+func (service *IdempotencyService) PurgeExpiredIdempotencyKeys() (int64, error) {
+	return service.idempotencyRepository.DeleteIdempotencyKeysBefore(time.Now().Add(-idempotencyKeyTTL))
+}
+
+// StartIdempotencyKeyPurge deletes expired idempotency keys now and then
+// every interval in the background.
+func (service *IdempotencyService) StartIdempotencyKeyPurge(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			count, err := service.PurgeExpiredIdempotencyKeys()
+			if err != nil {
+				log.Printf("Error purging expired idempotency keys: %v", err)
+			} else if count > 0 {
+				log.Printf("Purged %d expired idempotency key(s)", count)
+			}
+
+			<-ticker.C
+		}
+	}()
+}
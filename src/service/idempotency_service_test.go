@@ -0,0 +1,67 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestStoredResponseSecrets(t *testing.T) {
+	service := &IdempotencyService{secretCipher: newSecretCipher()}
+	body := []byte(`{"message":"Loan transaction created successfully","id":"abc","tracking_token":"secret-token"}`)
+
+	response, secrets, err := service.splitSecrets("application/json; charset=utf-8", body)
+	if err != nil {
+		t.Fatalf("splitSecrets() = %v", err)
+	}
+	if bytes.Contains(response, []byte("secret-token")) || bytes.Contains(secrets, []byte("secret-token")) {
+		t.Fatalf("splitSecrets() stored the token in plain text: response %s, secrets %q", response, secrets)
+	}
+
+	replayed, err := service.restoreSecrets(response, secrets)
+	if err != nil {
+		t.Fatalf("restoreSecrets() = %v", err)
+	}
+
+	var want, got map[string]any
+	if err := json.Unmarshal(body, &want); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(replayed, &got); err != nil {
+		t.Fatalf("restoreSecrets() returned invalid JSON %s: %v", replayed, err)
+	}
+	if len(got) != len(want) || got["tracking_token"] != want["tracking_token"] || got["id"] != want["id"] {
+		t.Fatalf("restoreSecrets() = %s, want %s", replayed, body)
+	}
+}
+
+func TestStoredResponseWithoutSecrets(t *testing.T) {
+	service := &IdempotencyService{secretCipher: newSecretCipher()}
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+	}{
+		{"json without secrets", "application/json", `{"message":"ok"}`},
+		{"plain text", "text/plain; charset=utf-8", "tracking_token"},
+		{"json array", "application/json", `[{"tracking_token":"x"}]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response, secrets, err := service.splitSecrets(tt.contentType, []byte(tt.body))
+			if err != nil {
+				t.Fatalf("splitSecrets() = %v", err)
+			}
+			if string(response) != tt.body || secrets != nil {
+				t.Fatalf("splitSecrets() = %s, %q, want the body unchanged", response, secrets)
+			}
+
+			replayed, err := service.restoreSecrets(response, secrets)
+			if err != nil || string(replayed) != tt.body {
+				t.Fatalf("restoreSecrets() = %s, %v, want %s", replayed, err, tt.body)
+			}
+		})
+	}
+}
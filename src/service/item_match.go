@@ -0,0 +1,157 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	"gtihub.com/raditsoic/telkom-storage-ms/src/database/repository"
+	"gtihub.com/raditsoic/telkom-storage-ms/src/model"
+	"gtihub.com/raditsoic/telkom-storage-ms/src/utils"
+)
+
+// normalizeItemName folds case and collapses whitespace so that names typed
+// slightly differently compare equal.
+func normalizeItemName(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
+
+// editDistance counts the single-character edits needed to turn a into b.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	previous := make([]int, len(rb)+1)
+	current := make([]int, len(rb)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		current[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+
+	return previous[len(rb)]
+}
+
+// isSimilarItemName reports whether two normalized names are likely to mean
+// the same item: a typo apart, or one contained in the other.
+func isSimilarItemName(a, b string) bool {
+	if strings.Contains(a, b) || strings.Contains(b, a) {
+		return true
+	}
+
+	limit := 2
+	if len([]rune(a)) <= 4 || len([]rune(b)) <= 4 {
+		limit = 1
+	}
+
+	return editDistance(a, b) <= limit
+}
+
+// matchInsertionItem looks for the item an insertion request refers to. The
+// match must have the same normalized name and category; items in the same
+// storage with a similar name, or the same name in another category, are
+// returned as suggestions.
+func matchInsertionItem(itemRepository *repository.ItemRepository, request model.ItemRequestDTO) (*model.Item, []model.Item, error) {
+	items, err := itemRepository.GetItemsInStorageOfCategory(request.CategoryID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	name := normalizeItemName(request.Name)
+	var match *model.Item
+	suggestions := []model.Item{}
+	for i := range items {
+		candidate := normalizeItemName(items[i].Name)
+		switch {
+		case candidate == name && items[i].CategoryID == request.CategoryID && match == nil:
+			match = &items[i]
+		case isSimilarItemName(candidate, name):
+			suggestions = append(suggestions, items[i])
+		}
+	}
+
+	return match, suggestions, nil
+}
+
+// stockInsertion adds the quantity of a completed insertion to stock and
+// returns the item it went to. The admin can name an existing item or ask for
+// a new one; otherwise an exact match is topped up, and a new item is only
+// created when nothing similar exists.
+func stockInsertion(itemRepository *repository.ItemRepository, request model.ItemRequestDTO, req model.UpdateTransactionStatusRequest) (uint, error) {
+	if req.ItemID != nil && req.CreateNewItem {
+		return 0, utils.ErrItemChoiceConflict
+	}
+
+	var existing *model.Item
+	switch {
+	case req.ItemID != nil:
+		existing = &model.Item{ID: *req.ItemID}
+	case !req.CreateNewItem:
+		match, suggestions, err := matchInsertionItem(itemRepository, request)
+		if err != nil {
+			return 0, err
+		}
+		if match == nil && len(suggestions) > 0 {
+			return 0, utils.ErrSimilarItemExists
+		}
+		existing = match
+	}
+
+	if existing != nil {
+		if _, err := itemRepository.GetItemByIDForUpdate(existing.ID); err != nil {
+			return 0, utils.ErrItemNotFound
+		}
+		if err := itemRepository.AdjustItemQuantity(existing.ID, request.Quantity, MovementInsertion); err != nil {
+			return 0, fmt.Errorf("failed to update existing item: %w", err)
+		}
+
+		return existing.ID, nil
+	}
+
+	createdItem, err := itemRepository.CreateItem(&model.Item{
+		Name:       strings.Join(strings.Fields(request.Name), " "),
+		Quantity:   request.Quantity,
+		Shelf:      request.Shelf,
+		CategoryID: request.CategoryID,
+	}, MovementInsertion)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create new item: %w", err)
+	}
+
+	return createdItem.ID, nil
+}
+
+// GetInsertionItemMatches shows which item completing an insertion would add
+// to, and which similar items the admin might mean instead.
+func (s *TransactionService) GetInsertionItemMatches(uuidStr string) (*model.InsertionItemMatchResponse, error) {
+	transactionType, uuid, err := parseTransactionID(uuidStr)
+	if err != nil {
+		return nil, err
+	}
+	if transactionType != "insert" {
+		return nil, utils.ErrTransactionType
+	}
+
+	insertion, err := s.logRepository.GetInsertionTransactionByUUID(uuid)
+	if err != nil {
+		return nil, utils.ErrTransactionNotFound
+	}
+
+	match, suggestions, err := matchInsertionItem(&s.itemRepository, insertion.ItemRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.InsertionItemMatchResponse{
+		ID:          uuidStr,
+		Match:       match,
+		Suggestions: suggestions,
+	}, nil
+}
@@ -1,9 +1,12 @@
 package service
 
 import (
+	"errors"
 	"fmt"
 	"strconv"
+	"time"
 
+	"gorm.io/gorm"
 	"gtihub.com/raditsoic/telkom-storage-ms/src/database/repository"
 	"gtihub.com/raditsoic/telkom-storage-ms/src/model"
 	"gtihub.com/raditsoic/telkom-storage-ms/src/utils"
@@ -31,8 +34,10 @@ func (service *ItemService) GetItems(pageParam, limitParam string) ([]model.Item
 	return service.itemRepository.GetItems(limit, offset)
 }
 
-func (service *ItemService) CreateItem(item *model.Item) (*model.Item, error) {
-	item, err := service.itemRepository.CreateItem(item)
+func (service *ItemService) CreateItem(item *model.Item, actorID *uint) (*model.Item, error) {
+	item.Reserved = 0
+
+	item, err := service.itemRepository.WithSource("", actorID).CreateItem(item, MovementItemCreated)
 	if err != nil {
 		return nil, err
 	}
@@ -51,6 +56,10 @@ func (service *ItemService) DeleteItem(id string) (*model.DeleteItemResponse, er
 
 	err := service.itemRepository.DeleteItem(id)
 	if err != nil {
+		// Items that transaction lines point to are kept for their history.
+		if errors.Is(err, gorm.ErrForeignKeyViolated) {
+			return nil, utils.ErrItemInUse
+		}
 		return nil, err
 	}
 
@@ -87,6 +96,43 @@ func (service *ItemService) UpdateItemName(id, new_name string) (*model.UpdateCa
 	return response, nil
 }
 
+// GetItemMovements lists the stock ledger of an item, newest first.
+func (service *ItemService) GetItemMovements(id, pageParam, limitParam string) ([]model.ItemMovement, error) {
+	item, err := service.itemRepository.GetItemByID(id)
+	if err != nil {
+		return nil, utils.ErrItemNotFound
+	}
+
+	page, limit := 1, 50
+	if parsedPage, err := strconv.Atoi(pageParam); err == nil && parsedPage > 0 {
+		page = parsedPage
+	}
+	if parsedLimit, err := strconv.Atoi(limitParam); err == nil && parsedLimit > 0 {
+		limit = parsedLimit
+	}
+
+	return service.itemRepository.GetItemMovements(item.ID, limit, (page-1)*limit)
+}
+
+// GetItemBalance returns the quantity an item had at the given time.
+func (service *ItemService) GetItemBalance(id string, at time.Time) (*model.ItemBalanceResponse, error) {
+	item, err := service.itemRepository.GetItemByID(id)
+	if err != nil {
+		return nil, utils.ErrItemNotFound
+	}
+
+	balance, err := service.itemRepository.GetItemBalanceAt(item.ID, at)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.ItemBalanceResponse{
+		ItemID:  item.ID,
+		Time:    at,
+		Balance: balance,
+	}, nil
+}
+
 func (service *ItemService) ExportItems () ([]model.ExportItem, error) {
 	return service.itemRepository.ExportItems()
 }
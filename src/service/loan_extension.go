@@ -0,0 +1,209 @@
+package service
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gtihub.com/raditsoic/telkom-storage-ms/src/model"
+	"gtihub.com/raditsoic/telkom-storage-ms/src/utils"
+)
+
+// Loan extension statuses
+const (
+	ExtensionPending  = "pending"
+	ExtensionApproved = "approved"
+	ExtensionRejected = "rejected"
+)
+
+// RequestLoanExtension asks for a loan's return time to be moved to a later
+// date. Only one extension per loan can wait for a decision at a time. The
+// borrower is authorized by the tracking token issued with the loan.
+func (s *TransactionService) RequestLoanExtension(uuidStr, token string, req model.CreateLoanExtensionRequest) (*model.LoanExtensionResponse, error) {
+	if token == "" {
+		return nil, utils.ErrTrackingTokenRequired
+	}
+
+	transactionType, uuid, err := parseTransactionID(uuidStr)
+	if err != nil {
+		return nil, err
+	}
+	if transactionType != "loan" {
+		return nil, utils.ErrTransactionType
+	}
+
+	req.Reason = strings.TrimSpace(req.Reason)
+	if req.Reason == "" {
+		return nil, utils.ErrReasonRequired
+	}
+
+	var extension *model.LoanExtension
+	err = s.logRepository.Transaction(func(tx *gorm.DB) error {
+		logRepository := s.logRepository.WithTx(tx)
+
+		loan, err := logRepository.GetLoanTransactionByUUIDForUpdate(uuid)
+		if err != nil {
+			return utils.ErrTransactionNotFound
+		}
+		if err := checkTrackingToken(loan.TrackingTokenHash, token); err != nil {
+			return err
+		}
+
+		switch loan.Status {
+		case StatusPending, StatusApproved, StatusCompleted, StatusOverdue:
+		default:
+			return &utils.TransitionError{TransactionType: "loan", From: loan.Status, To: "extended"}
+		}
+
+		if !req.ReturnTime.After(loan.ReturnTime) {
+			return utils.ErrInvalidReturnTime
+		}
+
+		pending, err := logRepository.HasPendingLoanExtension(loan.ID)
+		if err != nil {
+			return err
+		}
+		if pending {
+			return utils.ErrExtensionPending
+		}
+
+		extension = &model.LoanExtension{
+			LoanTransactionID:   loan.ID,
+			PreviousReturnTime:  loan.ReturnTime,
+			RequestedReturnTime: req.ReturnTime,
+			Reason:              req.Reason,
+			Status:              ExtensionPending,
+			RequestedTime:       time.Now(),
+		}
+
+		return logRepository.CreateLoanExtension(extension)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.LoanExtensionResponse{
+		Message:   "Loan extension requested successfully",
+		ID:        uuid.String(),
+		Extension: *extension,
+	}, nil
+}
+
+func (s *TransactionService) GetLoanExtensions(uuidStr string) ([]model.LoanExtension, error) {
+	transactionType, uuid, err := parseTransactionID(uuidStr)
+	if err != nil {
+		return nil, err
+	}
+	if transactionType != "loan" {
+		return nil, utils.ErrTransactionType
+	}
+
+	loan, err := s.logRepository.GetLoanTransactionByUUID(uuid)
+	if err != nil {
+		return nil, utils.ErrTransactionNotFound
+	}
+
+	return s.logRepository.GetLoanExtensions(loan.ID)
+}
+
+// DecideLoanExtension approves or rejects a pending extension on behalf of an
+// admin. Only a loan with units out can be extended: one that is completed or
+// overdue, or approved with some lines already handed out. Approving moves
+// the loan's return time and lifts the overdue status if the new date is
+// still ahead.
+func (s *TransactionService) DecideLoanExtension(uuidStr, extensionIDStr, status string, adminID *uint) (*model.LoanExtensionResponse, error) {
+	transactionType, uuid, err := parseTransactionID(uuidStr)
+	if err != nil {
+		return nil, err
+	}
+	if transactionType != "loan" {
+		return nil, utils.ErrTransactionType
+	}
+
+	extensionID, err := strconv.ParseUint(extensionIDStr, 10, 32)
+	if err != nil {
+		return nil, utils.ErrInvalidID
+	}
+
+	status = strings.ToLower(status)
+	if status != ExtensionApproved && status != ExtensionRejected {
+		return nil, utils.ErrInvalidStatus
+	}
+
+	var extension *model.LoanExtension
+	err = s.logRepository.Transaction(func(tx *gorm.DB) error {
+		logRepository := s.logRepository.WithTx(tx)
+
+		loan, err := logRepository.GetLoanTransactionByUUIDForUpdate(uuid)
+		if err != nil {
+			return utils.ErrTransactionNotFound
+		}
+
+		extension, err = logRepository.GetLoanExtensionForUpdate(loan.ID, uint(extensionID))
+		if err != nil {
+			return utils.ErrExtensionNotFound
+		}
+		if extension.Status != ExtensionPending {
+			return &utils.TransitionError{TransactionType: "loan extension", From: extension.Status, To: status}
+		}
+
+		if status == ExtensionApproved {
+			lines, err := logRepository.GetTransactionLinesForUpdate("loan", loan.ID)
+			if err != nil {
+				return err
+			}
+			if !canExtendLoan(loan.Status, lines) {
+				return &utils.TransitionError{TransactionType: "loan", From: loan.Status, To: "extended"}
+			}
+		}
+
+		now := time.Now()
+		extension.Status = status
+		extension.DecidedBy = adminID
+		extension.DecidedTime = &now
+		if err := logRepository.UpdateLoanExtension(extension); err != nil {
+			return err
+		}
+
+		if status == ExtensionRejected {
+			return nil
+		}
+
+		loan.ReturnTime = extension.RequestedReturnTime
+		if loan.OverdueTime != nil && loan.ReturnTime.After(now) {
+			if loan.Status == StatusOverdue {
+				if err := recordStatusChange(logRepository, "loan", loan.UUID, nil, loan.Status, StatusCompleted, adminID, "", "Loan extension approved"); err != nil {
+					return err
+				}
+				loan.Status = StatusCompleted
+			}
+			loan.OverdueTime = nil
+		}
+
+		return logRepository.UpdateLoanTransaction(loan)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.LoanExtensionResponse{
+		Message:   fmt.Sprintf("Loan extension %s successfully", status),
+		ID:        uuid.String(),
+		Extension: *extension,
+	}, nil
+}
+
+// canExtendLoan reports whether a loan in the given status still has units
+// out whose return time an extension could move.
+func canExtendLoan(status string, lines []model.TransactionLine) bool {
+	switch status {
+	case StatusCompleted, StatusOverdue:
+		return true
+	case StatusApproved:
+		return outstandingQuantity(lines) > 0
+	default:
+		return false
+	}
+}
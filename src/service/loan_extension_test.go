@@ -0,0 +1,28 @@
+package service
+
+import "testing"
+
+func TestCanExtendLoan(t *testing.T) {
+	tests := []struct {
+		name   string
+		status string
+		lines  []string
+		want   bool
+	}{
+		{"completed", StatusCompleted, []string{StatusCompleted}, true},
+		{"overdue", StatusOverdue, []string{StatusCompleted}, true},
+		{"approved with a line handed out", StatusApproved, []string{StatusCompleted, StatusApproved}, true},
+		{"approved with nothing handed out", StatusApproved, []string{StatusApproved, StatusPending}, false},
+		{"pending", StatusPending, []string{StatusPending}, false},
+		{"returned", StatusReturned, []string{StatusReturned}, false},
+		{"cancelled", StatusCancelled, []string{StatusCancelled}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := canExtendLoan(tt.status, linesWithStatuses(tt.lines...)); got != tt.want {
+				t.Fatalf("canExtendLoan(%q) = %v, want %v", tt.status, got, tt.want)
+			}
+		})
+	}
+}
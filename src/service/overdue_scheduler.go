@@ -0,0 +1,85 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"gorm.io/gorm"
+	"gtihub.com/raditsoic/telkom-storage-ms/src/model"
+)
+
+// StartOverdueScheduler checks for overdue loans right away and then once every
+// interval for as long as the server runs.
+func (s *TransactionService) StartOverdueScheduler(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			count, err := s.MarkOverdueLoans()
+			if err != nil {
+				log.Printf("Error marking overdue loans: %v", err)
+			} else if count > 0 {
+				log.Printf("Marked %d loan(s) as overdue", count)
+			}
+
+			<-ticker.C
+		}
+	}()
+}
+
+// MarkOverdueLoans flags loans that are past their return time with units
+// still out and returns how many of them moved to the overdue status.
+// Approved loans with some lines handed out are flagged without a status
+// change, since their other lines are still undecided.
+func (s *TransactionService) MarkOverdueLoans() (int, error) {
+	var count int
+	err := s.logRepository.Transaction(func(tx *gorm.DB) error {
+		logRepository := s.logRepository.WithTx(tx)
+
+		loans, err := logRepository.MarkOverdueLoans(time.Now())
+		if err != nil {
+			return err
+		}
+
+		for _, loan := range loans {
+			if err := recordStatusChange(logRepository, "loan", loan.UUID, nil, StatusCompleted, StatusOverdue, nil, "", "Return time passed"); err != nil {
+				return err
+			}
+		}
+
+		count = len(loans)
+		return nil
+	})
+
+	return count, err
+}
+
+func (s *TransactionService) GetOverdueLoans() ([]model.OverdueLoanResponse, error) {
+	loans, err := s.logRepository.GetOverdueLoanTransactions()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	overdue := make([]model.OverdueLoanResponse, 0, len(loans))
+	for _, loan := range loans {
+		overdue = append(overdue, model.OverdueLoanResponse{
+			UUID:                fmt.Sprintf("%s_%s", "loan", loan.UUID),
+			EmployeeName:        loan.EmployeeName,
+			EmployeeDepartment:  loan.EmployeeDepartment,
+			EmployeePosition:    loan.EmployeePosition,
+			Item:                loan.Item,
+			Quantity:            loan.Quantity,
+			OutstandingQuantity: loan.OutstandingQuantity,
+			LoanTime:            loan.LoanTime,
+			ReturnTime:          loan.ReturnTime,
+			OverdueTime:         loan.OverdueTime,
+			DaysLate:            int(math.Ceil(now.Sub(loan.ReturnTime).Hours() / 24)),
+		})
+	}
+
+	return overdue, nil
+}
@@ -0,0 +1,295 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gtihub.com/raditsoic/telkom-storage-ms/src/database/repository"
+	"gtihub.com/raditsoic/telkom-storage-ms/src/model"
+	"gtihub.com/raditsoic/telkom-storage-ms/src/utils"
+)
+
+// Quota enforcement modes
+const (
+	QuotaFlag  = "flag"
+	QuotaBlock = "block"
+)
+
+// quotaLineStatuses are the inquiry line statuses that count against a
+// quota. Requests still waiting for a decision count too, so that several
+// pending requests cannot add up past it.
+var quotaLineStatuses = []string{StatusPending, StatusApproved, StatusCompleted}
+
+type QuotaService struct {
+	quotaRepository repository.QuotaRepository
+}
+
+func NewQuotaService(quota repository.QuotaRepository) *QuotaService {
+	return &QuotaService{quotaRepository: quota}
+}
+
+func parseQuotaID(idStr string) (uint, error) {
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		return 0, utils.ErrInvalidID
+	}
+
+	return uint(id), nil
+}
+
+// quotaPeriodStart is the start of the rolling period of a quota.
+func quotaPeriodStart(quota model.ConsumptionQuota, now time.Time) time.Time {
+	return now.AddDate(0, 0, -quota.PeriodDays)
+}
+
+// applyQuotaRequest validates a create or update request and copies it onto
+// a quota. A quota covers either an employee or a department, and either an
+// item or a category.
+func applyQuotaRequest(quota *model.ConsumptionQuota, req model.QuotaRequest) error {
+	department := strings.TrimSpace(req.Department)
+	if (req.EmployeeID == nil) == (department == "") {
+		return fmt.Errorf("%w: set either employee_id or department", utils.ErrInvalidQuota)
+	}
+	if (req.ItemID == nil) == (req.CategoryID == nil) {
+		return fmt.Errorf("%w: set either item_id or category_id", utils.ErrInvalidQuota)
+	}
+	if req.Quantity <= 0 {
+		return fmt.Errorf("%w: quantity must be greater than 0", utils.ErrInvalidQuota)
+	}
+	if req.PeriodDays <= 0 {
+		return fmt.Errorf("%w: period_days must be greater than 0", utils.ErrInvalidQuota)
+	}
+
+	enforcement := strings.ToLower(strings.TrimSpace(req.Enforcement))
+	switch enforcement {
+	case "":
+		enforcement = QuotaFlag
+	case QuotaFlag, QuotaBlock:
+	default:
+		return fmt.Errorf("%w: enforcement must be flag or block", utils.ErrInvalidQuota)
+	}
+
+	quota.EmployeeID = req.EmployeeID
+	quota.Department = department
+	quota.PerEmployee = req.PerEmployee && req.EmployeeID == nil
+	quota.ItemID = req.ItemID
+	quota.CategoryID = req.CategoryID
+	quota.Quantity = req.Quantity
+	quota.PeriodDays = req.PeriodDays
+	quota.Enforcement = enforcement
+	quota.Notes = req.Notes
+
+	return nil
+}
+
+func (service *QuotaService) CreateQuota(req model.QuotaRequest, adminID *uint) (*model.QuotaResponse, error) {
+	quota := &model.ConsumptionQuota{CreatedBy: adminID, CreatedTime: time.Now()}
+	if err := applyQuotaRequest(quota, req); err != nil {
+		return nil, err
+	}
+
+	if err := service.quotaRepository.CreateQuota(quota); err != nil {
+		if errors.Is(err, gorm.ErrForeignKeyViolated) {
+			return nil, utils.ErrQuotaTargetNotFound
+		}
+		return nil, err
+	}
+
+	return &model.QuotaResponse{
+		Message: "Quota created successfully",
+		ID:      strconv.FormatUint(uint64(quota.ID), 10),
+		Quota:   *quota,
+	}, nil
+}
+
+func (service *QuotaService) UpdateQuota(idStr string, req model.QuotaRequest) (*model.QuotaResponse, error) {
+	id, err := parseQuotaID(idStr)
+	if err != nil {
+		return nil, err
+	}
+
+	quota, err := service.quotaRepository.GetQuotaByID(id)
+	if err != nil {
+		return nil, utils.ErrQuotaNotFound
+	}
+
+	if err := applyQuotaRequest(quota, req); err != nil {
+		return nil, err
+	}
+
+	if err := service.quotaRepository.UpdateQuota(quota); err != nil {
+		if errors.Is(err, gorm.ErrForeignKeyViolated) {
+			return nil, utils.ErrQuotaTargetNotFound
+		}
+		return nil, err
+	}
+
+	return &model.QuotaResponse{
+		Message: "Quota updated successfully",
+		ID:      idStr,
+		Quota:   *quota,
+	}, nil
+}
+
+func (service *QuotaService) DeleteQuota(idStr string) (*model.DeleteQuotaResponse, error) {
+	id, err := parseQuotaID(idStr)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := service.quotaRepository.GetQuotaByID(id); err != nil {
+		return nil, utils.ErrQuotaNotFound
+	}
+
+	if err := service.quotaRepository.DeleteQuota(id); err != nil {
+		return nil, err
+	}
+
+	return &model.DeleteQuotaResponse{
+		Message: "Quota deleted successfully",
+		ID:      idStr,
+	}, nil
+}
+
+// GetQuotaUsages reports how much of each quota has been used in its current
+// period, optionally only for one employee or department.
+func (service *QuotaService) GetQuotaUsages(employeeID *uint, department string) ([]model.QuotaUsage, error) {
+	quotas, err := service.quotaRepository.GetQuotas(employeeID, strings.TrimSpace(department))
+	if err != nil {
+		return nil, err
+	}
+
+	usages := make([]model.QuotaUsage, 0, len(quotas))
+	for _, quota := range quotas {
+		usage, err := service.quotaUsage(quota)
+		if err != nil {
+			return nil, err
+		}
+		usages = append(usages, *usage)
+	}
+
+	return usages, nil
+}
+
+func (service *QuotaService) GetQuotaUsage(idStr string) (*model.QuotaUsage, error) {
+	id, err := parseQuotaID(idStr)
+	if err != nil {
+		return nil, err
+	}
+
+	quota, err := service.quotaRepository.GetQuotaByID(id)
+	if err != nil {
+		return nil, utils.ErrQuotaNotFound
+	}
+
+	return service.quotaUsage(*quota)
+}
+
+func (service *QuotaService) quotaUsage(quota model.ConsumptionQuota) (*model.QuotaUsage, error) {
+	since := quotaPeriodStart(quota, time.Now())
+	usage := &model.QuotaUsage{Quota: quota, PeriodStart: since}
+
+	if quota.PerEmployee {
+		employees, err := service.quotaRepository.GetQuotaUsageByEmployee(quota, since, quotaLineStatuses)
+		if err != nil {
+			return nil, err
+		}
+		for i := range employees {
+			employees[i].Remaining = max(quota.Quantity-employees[i].Used, 0)
+			employees[i].Exceeded = employees[i].Used > quota.Quantity
+			usage.Used = max(usage.Used, employees[i].Used)
+		}
+		usage.Employees = employees
+	} else {
+		used, err := service.quotaRepository.GetQuotaUsage(quota, nil, "", since, quotaLineStatuses)
+		if err != nil {
+			return nil, err
+		}
+		usage.Used = used
+	}
+
+	usage.Remaining = max(quota.Quantity-usage.Used, 0)
+	usage.Exceeded = usage.Used > quota.Quantity
+	return usage, nil
+}
+
+// checkQuotas compares a new inquiry with the quotas covering its requester
+// and items. It fails with ErrQuotaExceeded when a blocking quota would be
+// exceeded, and otherwise returns the flagging quotas it goes over.
+func checkQuotas(quotaRepository *repository.QuotaRepository, employeeID *uint, employeeName, department string, lines []model.TransactionLine, items []*model.Item) ([]model.QuotaWarning, error) {
+	itemIDs := make([]uint, 0, len(lines))
+	categoryIDs := make([]uint, 0, len(items))
+	for i := range lines {
+		itemIDs = append(itemIDs, lines[i].ItemID)
+		categoryIDs = append(categoryIDs, items[i].CategoryID)
+	}
+
+	quotas, err := quotaRepository.GetApplicableQuotasForUpdate(employeeID, strings.TrimSpace(department), itemIDs, categoryIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	warnings := []model.QuotaWarning{}
+	for _, quota := range quotas {
+		requested := quotaRequested(quota, lines, items)
+		if requested == 0 {
+			continue
+		}
+
+		used, err := quotaRepository.GetQuotaUsage(quota, employeeID, normalizeEmployeeName(employeeName), quotaPeriodStart(quota, now), quotaLineStatuses)
+		if err != nil {
+			return nil, err
+		}
+
+		warning, err := applyQuota(quota, used, requested)
+		if err != nil {
+			return nil, err
+		}
+		if warning != nil {
+			warnings = append(warnings, *warning)
+		}
+	}
+
+	return warnings, nil
+}
+
+// quotaRequested counts the units of a request that a quota covers. Lines
+// and items are paired by index.
+func quotaRequested(quota model.ConsumptionQuota, lines []model.TransactionLine, items []*model.Item) int {
+	requested := 0
+	for i := range lines {
+		if (quota.ItemID != nil && *quota.ItemID == lines[i].ItemID) || (quota.CategoryID != nil && *quota.CategoryID == items[i].CategoryID) {
+			requested += lines[i].Quantity
+		}
+	}
+
+	return requested
+}
+
+// applyQuota decides what happens to a request for requested more units of a
+// quota that has used units taken already. Within the quota it returns
+// neither a warning nor an error; over it, a blocking quota fails with
+// ErrQuotaExceeded and a flagging one returns a warning.
+func applyQuota(quota model.ConsumptionQuota, used, requested int) (*model.QuotaWarning, error) {
+	if used+requested <= quota.Quantity {
+		return nil, nil
+	}
+
+	if quota.Enforcement == QuotaBlock {
+		return nil, fmt.Errorf("%w: %d of %d allowed per %d days already used, %d requested", utils.ErrQuotaExceeded, used, quota.Quantity, quota.PeriodDays, requested)
+	}
+
+	return &model.QuotaWarning{
+		QuotaID:     quota.ID,
+		Enforcement: quota.Enforcement,
+		Limit:       quota.Quantity,
+		Used:        used,
+		Requested:   requested,
+		PeriodDays:  quota.PeriodDays,
+	}, nil
+}
@@ -0,0 +1,94 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"gtihub.com/raditsoic/telkom-storage-ms/src/model"
+	"gtihub.com/raditsoic/telkom-storage-ms/src/utils"
+)
+
+func uintPtr(v uint) *uint {
+	return &v
+}
+
+func TestQuotaPeriodStart(t *testing.T) {
+	now := time.Date(2024, time.March, 10, 15, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		periodDays int
+		want       time.Time
+	}{
+		{"one day", 1, time.Date(2024, time.March, 9, 15, 30, 0, 0, time.UTC)},
+		{"one week", 7, time.Date(2024, time.March, 3, 15, 30, 0, 0, time.UTC)},
+		{"across a leap day", 30, time.Date(2024, time.February, 9, 15, 30, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := quotaPeriodStart(model.ConsumptionQuota{PeriodDays: tt.periodDays}, now)
+			if !got.Equal(tt.want) {
+				t.Fatalf("quotaPeriodStart(%d days) = %v, want %v", tt.periodDays, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQuotaRequested(t *testing.T) {
+	lines := []model.TransactionLine{{ItemID: 1, Quantity: 2}, {ItemID: 2, Quantity: 3}, {ItemID: 3, Quantity: 4}}
+	items := []*model.Item{{ID: 1, CategoryID: 10}, {ID: 2, CategoryID: 10}, {ID: 3, CategoryID: 20}}
+
+	tests := []struct {
+		name  string
+		quota model.ConsumptionQuota
+		want  int
+	}{
+		{"item quota", model.ConsumptionQuota{ItemID: uintPtr(2)}, 3},
+		{"category quota", model.ConsumptionQuota{CategoryID: uintPtr(10)}, 5},
+		{"quota for other items", model.ConsumptionQuota{ItemID: uintPtr(9)}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := quotaRequested(tt.quota, lines, items); got != tt.want {
+				t.Fatalf("quotaRequested() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyQuota(t *testing.T) {
+	tests := []struct {
+		name        string
+		enforcement string
+		used        int
+		requested   int
+		wantWarning bool
+		wantErr     error
+	}{
+		{"within the quota", QuotaBlock, 4, 5, false, nil},
+		{"exactly at the quota", QuotaFlag, 7, 3, false, nil},
+		{"flagging quota exceeded", QuotaFlag, 8, 3, true, nil},
+		{"blocking quota exceeded", QuotaBlock, 8, 3, false, utils.ErrQuotaExceeded},
+		{"single request over the quota", QuotaBlock, 0, 11, false, utils.ErrQuotaExceeded},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			quota := model.ConsumptionQuota{ID: 1, Quantity: 10, PeriodDays: 30, Enforcement: tt.enforcement}
+
+			warning, err := applyQuota(quota, tt.used, tt.requested)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("applyQuota() error = %v, want %v", err, tt.wantErr)
+			}
+			if (warning != nil) != tt.wantWarning {
+				t.Fatalf("applyQuota() warning = %+v, want warning %v", warning, tt.wantWarning)
+			}
+			if warning != nil && (warning.Used != tt.used || warning.Requested != tt.requested || warning.Limit != quota.Quantity) {
+				t.Fatalf("applyQuota() warning = %+v", warning)
+			}
+		})
+	}
+}
@@ -0,0 +1,359 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gtihub.com/raditsoic/telkom-storage-ms/src/database/repository"
+	"gtihub.com/raditsoic/telkom-storage-ms/src/model"
+	"gtihub.com/raditsoic/telkom-storage-ms/src/utils"
+)
+
+// Stock opname statuses
+const (
+	OpnameOpen      = "open"
+	OpnameCommitted = "committed"
+	OpnameCancelled = "cancelled"
+)
+
+// MovementStockOpname is the stock ledger reason of adjustments made when a
+// stock opname is committed.
+const MovementStockOpname = "stock_opname"
+
+type StockOpnameService struct {
+	opnameRepository  repository.StockOpnameRepository
+	itemRepository    repository.ItemRepository
+	storageRepository repository.StorageRepository
+}
+
+func NewStockOpnameService(opname repository.StockOpnameRepository, item repository.ItemRepository, storage repository.StorageRepository) *StockOpnameService {
+	return &StockOpnameService{opnameRepository: opname, itemRepository: item, storageRepository: storage}
+}
+
+func parseStockOpnameID(idStr string) (uint, error) {
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		return 0, utils.ErrInvalidID
+	}
+
+	return uint(id), nil
+}
+
+// OpenStockOpname starts counting a storage. A storage can only have one open
+// count at a time.
+func (service *StockOpnameService) OpenStockOpname(storageIDStr string, req model.OpenStockOpnameRequest, adminID *uint) (*model.StockOpnameResponse, error) {
+	storageID, err := strconv.Atoi(storageIDStr)
+	if err != nil {
+		return nil, utils.ErrInvalidID
+	}
+
+	if _, err := service.storageRepository.GetStorageByID(storageID); err != nil {
+		return nil, utils.ErrStorageNotFound
+	}
+
+	opname := &model.StockOpname{
+		StorageID:  storageID,
+		Status:     OpnameOpen,
+		Notes:      req.Notes,
+		OpenedBy:   adminID,
+		OpenedTime: time.Now(),
+	}
+	if err := service.opnameRepository.CreateStockOpname(opname); err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return nil, utils.ErrStockOpnameInProgress
+		}
+		return nil, err
+	}
+
+	return &model.StockOpnameResponse{
+		Message: "Stock opname opened successfully",
+		ID:      strconv.FormatUint(uint64(opname.ID), 10),
+		Opname:  *opname,
+	}, nil
+}
+
+func (service *StockOpnameService) GetStockOpnames() ([]model.StockOpname, error) {
+	return service.opnameRepository.GetStockOpnames()
+}
+
+func (service *StockOpnameService) GetStockOpname(idStr string) (*model.StockOpname, error) {
+	id, err := parseStockOpnameID(idStr)
+	if err != nil {
+		return nil, err
+	}
+
+	opname, err := service.opnameRepository.GetStockOpnameByID(id)
+	if err != nil {
+		return nil, utils.ErrStockOpnameNotFound
+	}
+
+	return opname, nil
+}
+
+// RecordStockOpnameCounts stores the quantities one counter found. Recounting
+// an item replaces the counter's earlier figure for it.
+func (service *StockOpnameService) RecordStockOpnameCounts(idStr string, req model.RecordStockOpnameCountsRequest, adminID *uint) (*model.StockOpnameResponse, error) {
+	id, err := parseStockOpnameID(idStr)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Counter = strings.TrimSpace(req.Counter)
+	if req.Counter == "" {
+		return nil, utils.ErrCounterRequired
+	}
+
+	err = service.opnameRepository.Transaction(func(tx *gorm.DB) error {
+		opnameRepository := service.opnameRepository.WithTx(tx)
+
+		opname, err := opnameRepository.GetStockOpnameForUpdate(id)
+		if err != nil {
+			return utils.ErrStockOpnameNotFound
+		}
+		if opname.Status != OpnameOpen {
+			return utils.ErrStockOpnameClosed
+		}
+
+		items, err := opnameRepository.GetStorageItems(opname.StorageID)
+		if err != nil {
+			return err
+		}
+		inStorage := make(map[uint]bool, len(items))
+		for _, item := range items {
+			inStorage[item.ID] = true
+		}
+
+		now := time.Now()
+		for _, count := range req.Counts {
+			if !inStorage[count.ItemID] {
+				return fmt.Errorf("item with ID %d is not kept in this storage: %w", count.ItemID, utils.ErrItemNotFound)
+			}
+			if count.Quantity < 0 {
+				return utils.ErrInvalidCount
+			}
+
+			if err := opnameRepository.SaveStockOpnameCount(&model.StockOpnameCount{
+				StockOpnameID: opname.ID,
+				ItemID:        count.ItemID,
+				Counter:       req.Counter,
+				Quantity:      count.Quantity,
+				AdminID:       adminID,
+				Time:          now,
+			}); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	opname, err := service.opnameRepository.GetStockOpnameByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.StockOpnameResponse{
+		Message: "Stock opname counts recorded successfully",
+		ID:      idStr,
+		Opname:  *opname,
+	}, nil
+}
+
+// GetStockOpnameVariance compares the counted quantities with the system
+// quantities. Committed counts return their frozen report instead.
+func (service *StockOpnameService) GetStockOpnameVariance(idStr string) (*model.StockOpnameVarianceResponse, error) {
+	id, err := parseStockOpnameID(idStr)
+	if err != nil {
+		return nil, err
+	}
+
+	opname, err := service.opnameRepository.GetStockOpnameByID(id)
+	if err != nil {
+		return nil, utils.ErrStockOpnameNotFound
+	}
+
+	response := &model.StockOpnameVarianceResponse{
+		ID:     opname.ID,
+		Status: opname.Status,
+		Lines:  opname.Report,
+	}
+	if opname.Status != OpnameOpen {
+		return response, nil
+	}
+
+	items, err := service.opnameRepository.GetStorageItems(opname.StorageID)
+	if err != nil {
+		return nil, err
+	}
+	response.Lines = buildStockOpnameReport(opname.ID, items, opname.Counts)
+
+	return response, nil
+}
+
+// CommitStockOpname adjusts the stock of every counted item to its counted
+// quantity and freezes the variance report. Items nobody counted are left as
+// they are. Stock cannot move in the storage while it is being counted, so
+// the system quantities are still the ones the count was taken against.
+func (service *StockOpnameService) CommitStockOpname(idStr string, adminID *uint) (*model.StockOpnameResponse, error) {
+	id, err := parseStockOpnameID(idStr)
+	if err != nil {
+		return nil, err
+	}
+
+	err = service.opnameRepository.Transaction(func(tx *gorm.DB) error {
+		opnameRepository := service.opnameRepository.WithTx(tx)
+		itemRepository := service.itemRepository.WithTx(tx).WithSource(fmt.Sprintf("opname_%d", id), adminID)
+
+		opname, err := opnameRepository.GetStockOpnameForUpdate(id)
+		if err != nil {
+			return utils.ErrStockOpnameNotFound
+		}
+		if opname.Status != OpnameOpen {
+			return utils.ErrStockOpnameClosed
+		}
+
+		counts, err := opnameRepository.GetStockOpnameCounts(opname.ID)
+		if err != nil {
+			return err
+		}
+		items, err := opnameRepository.GetStorageItems(opname.StorageID)
+		if err != nil {
+			return err
+		}
+
+		// Lock the counted items so the report reflects the quantities the
+		// adjustments are applied to.
+		for i := range items {
+			item, err := itemRepository.GetItemByIDForUpdate(items[i].ID)
+			if err != nil {
+				return utils.ErrItemNotFound
+			}
+			items[i] = *item
+		}
+
+		report := buildStockOpnameReport(opname.ID, items, counts)
+		if err := checkCountsCoverReserved(items, report); err != nil {
+			return err
+		}
+		for _, line := range report {
+			if line.Variance == 0 {
+				continue
+			}
+			if err := itemRepository.AdjustItemQuantity(line.ItemID, line.Variance, MovementStockOpname); err != nil {
+				return fmt.Errorf("failed to adjust item %d: %w", line.ItemID, err)
+			}
+		}
+		if err := opnameRepository.CreateStockOpnameReport(report); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		opname.Status = OpnameCommitted
+		opname.ClosedBy = adminID
+		opname.ClosedTime = &now
+		return opnameRepository.UpdateStockOpname(opname)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	opname, err := service.opnameRepository.GetStockOpnameByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.StockOpnameResponse{
+		Message: "Stock opname committed successfully",
+		ID:      idStr,
+		Opname:  *opname,
+	}, nil
+}
+
+// CancelStockOpname closes a count without touching the stock.
+func (service *StockOpnameService) CancelStockOpname(idStr string, adminID *uint) (*model.StockOpnameResponse, error) {
+	id, err := parseStockOpnameID(idStr)
+	if err != nil {
+		return nil, err
+	}
+
+	var opname *model.StockOpname
+	err = service.opnameRepository.Transaction(func(tx *gorm.DB) error {
+		opnameRepository := service.opnameRepository.WithTx(tx)
+
+		opname, err = opnameRepository.GetStockOpnameForUpdate(id)
+		if err != nil {
+			return utils.ErrStockOpnameNotFound
+		}
+		if opname.Status != OpnameOpen {
+			return utils.ErrStockOpnameClosed
+		}
+
+		now := time.Now()
+		opname.Status = OpnameCancelled
+		opname.ClosedBy = adminID
+		opname.ClosedTime = &now
+		return opnameRepository.UpdateStockOpname(opname)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.StockOpnameResponse{
+		Message: "Stock opname cancelled successfully",
+		ID:      idStr,
+		Opname:  *opname,
+	}, nil
+}
+
+// buildStockOpnameReport sums the counts of each item of the storage and
+// compares them with its system quantity.
+func buildStockOpnameReport(opnameID uint, items []model.Item, counts []model.StockOpnameCount) []model.StockOpnameReportLine {
+	counted := make(map[uint]int)
+	for _, count := range counts {
+		counted[count.ItemID] += count.Quantity
+	}
+
+	lines := make([]model.StockOpnameReportLine, 0, len(items))
+	for _, item := range items {
+		line := model.StockOpnameReportLine{
+			StockOpnameID:  opnameID,
+			ItemID:         item.ID,
+			ItemName:       item.Name,
+			Shelf:          item.Shelf,
+			SystemQuantity: item.Quantity,
+		}
+		if quantity, ok := counted[item.ID]; ok {
+			line.CountedQuantity = &quantity
+			line.Variance = quantity - item.Quantity
+		}
+
+		lines = append(lines, line)
+	}
+
+	return lines
+}
+
+// checkCountsCoverReserved refuses a count that found fewer units of an item
+// than approved transactions have reserved, since those would have nothing
+// left to hand out.
+func checkCountsCoverReserved(items []model.Item, report []model.StockOpnameReportLine) error {
+	reserved := make(map[uint]int, len(items))
+	for _, item := range items {
+		reserved[item.ID] = item.Reserved
+	}
+
+	for _, line := range report {
+		if line.CountedQuantity != nil && *line.CountedQuantity < reserved[line.ItemID] {
+			return fmt.Errorf("item %d (%s) was counted at %d but %d are reserved: %w", line.ItemID, line.ItemName, *line.CountedQuantity, reserved[line.ItemID], utils.ErrCountBelowReserved)
+		}
+	}
+
+	return nil
+}
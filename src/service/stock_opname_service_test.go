@@ -0,0 +1,55 @@
+package service
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"gtihub.com/raditsoic/telkom-storage-ms/src/model"
+	"gtihub.com/raditsoic/telkom-storage-ms/src/utils"
+)
+
+func TestBuildStockOpnameReport(t *testing.T) {
+	items := []model.Item{{ID: 1, Name: "Cable", Quantity: 10}, {ID: 2, Name: "Router", Quantity: 4}}
+	counts := []model.StockOpnameCount{{ItemID: 1, Counter: "a", Quantity: 6}, {ItemID: 1, Counter: "b", Quantity: 3}}
+
+	report := buildStockOpnameReport(7, items, counts)
+
+	nine := 9
+	want := []model.StockOpnameReportLine{
+		{StockOpnameID: 7, ItemID: 1, ItemName: "Cable", SystemQuantity: 10, CountedQuantity: &nine, Variance: -1},
+		{StockOpnameID: 7, ItemID: 2, ItemName: "Router", SystemQuantity: 4},
+	}
+	if !reflect.DeepEqual(report, want) {
+		t.Fatalf("buildStockOpnameReport() = %+v, want %+v", report, want)
+	}
+}
+
+func TestCheckCountsCoverReserved(t *testing.T) {
+	items := []model.Item{{ID: 1, Name: "Cable", Quantity: 10, Reserved: 4}, {ID: 2, Name: "Router", Quantity: 4, Reserved: 2}}
+
+	tests := []struct {
+		name   string
+		counts []model.StockOpnameCount
+		want   error
+	}{
+		{"counts above reserved", []model.StockOpnameCount{{ItemID: 1, Quantity: 5}, {ItemID: 2, Quantity: 2}}, nil},
+		{"uncounted item", []model.StockOpnameCount{{ItemID: 1, Quantity: 4}}, nil},
+		{"count below reserved", []model.StockOpnameCount{{ItemID: 1, Quantity: 8}, {ItemID: 2, Quantity: 1}}, utils.ErrCountBelowReserved},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkCountsCoverReserved(items, buildStockOpnameReport(1, items, tt.counts))
+			if tt.want == nil {
+				if err != nil {
+					t.Fatalf("checkCountsCoverReserved() = %v, want nil", err)
+				}
+				return
+			}
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("checkCountsCoverReserved() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,189 @@
+package service
+
+import (
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gtihub.com/raditsoic/telkom-storage-ms/src/model"
+	"gtihub.com/raditsoic/telkom-storage-ms/src/utils"
+)
+
+// withoutStatus returns a copy of statuses without the given one.
+func withoutStatus(statuses []string, status string) []string {
+	filtered := make([]string, 0, len(statuses))
+	for _, next := range statuses {
+		if next != status {
+			filtered = append(filtered, next)
+		}
+	}
+
+	return filtered
+}
+
+// GetTransactionApprovals lists the approval chain steps of a loan or
+// inquiry with the decision taken on each.
+func (s *TransactionService) GetTransactionApprovals(uuidStr string) (*model.TransactionApprovalsResponse, error) {
+	transactionType, uuid, err := parseTransactionID(uuidStr)
+	if err != nil {
+		return nil, err
+	}
+
+	var id uint
+	var status string
+	switch transactionType {
+	case "loan":
+		loan, err := s.logRepository.GetLoanTransactionByUUID(uuid)
+		if err != nil {
+			return nil, utils.ErrTransactionNotFound
+		}
+		id, status = loan.ID, loan.Status
+	case "inquiry":
+		inquiry, err := s.logRepository.GetInquiryTransactionByUUID(uuid)
+		if err != nil {
+			return nil, utils.ErrTransactionNotFound
+		}
+		id, status = inquiry.ID, inquiry.Status
+	default:
+		return nil, utils.ErrTransactionType
+	}
+
+	steps, err := s.logRepository.GetTransactionApprovals(transactionType, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.TransactionApprovalsResponse{
+		ID:     uuidStr,
+		Status: status,
+		Steps:  steps,
+	}, nil
+}
+
+// DecideApprovalStep approves or rejects the next waiting step of the
+// approval chain of a loan or inquiry on behalf of an admin holding its role.
+// Approving the last step approves the request itself, and rejecting any
+// step rejects it. An admin may decide only one step of the same request.
+func (s *TransactionService) DecideApprovalStep(uuidStr, status string, adminID *uint, req model.UpdateTransactionStatusRequest) (*model.TransactionApprovalsResponse, error) {
+	transactionType, uuid, err := parseTransactionID(uuidStr)
+	if err != nil {
+		return nil, err
+	}
+	if transactionType != "loan" && transactionType != "inquiry" {
+		return nil, utils.ErrTransactionType
+	}
+	if adminID == nil {
+		return nil, utils.ErrApproverRoleRequired
+	}
+
+	status = strings.ToLower(status)
+	if status != ApprovalStepApproved && status != ApprovalStepRejected {
+		return nil, utils.ErrInvalidStatus
+	}
+
+	req.Reason = strings.TrimSpace(req.Reason)
+	if status == ApprovalStepRejected && req.Reason == "" {
+		return nil, utils.ErrReasonRequired
+	}
+
+	var current string
+	var steps []model.TransactionApproval
+	err = s.logRepository.Transaction(func(tx *gorm.DB) error {
+		txService := s.withTx(tx)
+		logRepository := &txService.logRepository
+
+		var id uint
+		var department string
+		switch transactionType {
+		case "loan":
+			loan, err := logRepository.GetLoanTransactionByUUIDForUpdate(uuid)
+			if err != nil {
+				return utils.ErrTransactionNotFound
+			}
+			id, current, department = loan.ID, loan.Status, loan.EmployeeDepartment
+		case "inquiry":
+			inquiry, err := logRepository.GetInquiryTransactionByUUIDForUpdate(uuid)
+			if err != nil {
+				return utils.ErrTransactionNotFound
+			}
+			id, current, department = inquiry.ID, inquiry.Status, inquiry.EmployeeDepartment
+		}
+
+		if current != StatusPending && current != StatusIncomplete {
+			return &utils.TransitionError{TransactionType: transactionType, From: current, To: status}
+		}
+
+		steps, err = logRepository.GetTransactionApprovalsForUpdate(transactionType, id)
+		if err != nil {
+			return err
+		}
+
+		var step *model.TransactionApproval
+		for i := range steps {
+			if steps[i].Status == ApprovalStepPending {
+				step = &steps[i]
+				break
+			}
+			if steps[i].AdminID != nil && *steps[i].AdminID == *adminID {
+				return utils.ErrApproverAlreadyDecided
+			}
+		}
+		if step == nil {
+			return utils.ErrApprovalStepNotFound
+		}
+
+		roles, err := txService.chainRepository.GetAdminRoles(*adminID)
+		if err != nil {
+			return err
+		}
+		if !holdsApproverRole(roles, step.Role, department) {
+			return utils.ErrApproverRoleRequired
+		}
+
+		now := time.Now()
+		step.Status = status
+		step.AdminID = adminID
+		step.Reason = req.Reason
+		step.Comment = req.Comment
+		step.DecidedTime = &now
+		if err := logRepository.UpdateTransactionApproval(step); err != nil {
+			return err
+		}
+
+		last := true
+		for i := range steps {
+			if steps[i].Status != ApprovalStepPending {
+				continue
+			}
+			if status == ApprovalStepRejected {
+				steps[i].Status = ApprovalStepSkipped
+				if err := logRepository.UpdateTransactionApproval(&steps[i]); err != nil {
+					return err
+				}
+				continue
+			}
+			last = false
+		}
+
+		switch {
+		case status == ApprovalStepRejected:
+			current = StatusRejected
+		case last:
+			current = StatusApproved
+		default:
+			return nil
+		}
+
+		_, err = txService.UpdateTransactionStatus(current, uuidStr, adminID, req)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.TransactionApprovalsResponse{
+		ID:     uuidStr,
+		Status: current,
+		Steps:  steps,
+	}, nil
+}
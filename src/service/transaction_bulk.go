@@ -0,0 +1,116 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+	"gtihub.com/raditsoic/telkom-storage-ms/src/model"
+	"gtihub.com/raditsoic/telkom-storage-ms/src/utils"
+)
+
+// maxBulkTransactions caps how many transactions one bulk request may touch.
+const maxBulkTransactions = 100
+
+// errBulkRolledBack aborts an atomic bulk update once any of its
+// transactions failed.
+var errBulkRolledBack = errors.New("bulk status update rolled back")
+
+// withTx returns a copy of the service whose repositories are bound to the
+// given database transaction. Transactions it opens become savepoints.
+func (s *TransactionService) withTx(tx *gorm.DB) *TransactionService {
+	return &TransactionService{
+		logRepository:      *s.logRepository.WithTx(tx),
+		itemRepository:     *s.itemRepository.WithTx(tx),
+		opnameRepository:   *s.opnameRepository.WithTx(tx),
+		employeeRepository: s.employeeRepository,
+		quotaRepository:    *s.quotaRepository.WithTx(tx),
+		ruleRepository:     *s.ruleRepository.WithTx(tx),
+		chainRepository:    *s.chainRepository.WithTx(tx),
+		categoryRepository: s.categoryRepository,
+	}
+}
+
+// BulkUpdateTransactionStatus moves several transactions to the same status
+// and reports the outcome of each. Without atomic every transaction is
+// updated on its own; with atomic either all of them change or none do.
+func (s *TransactionService) BulkUpdateTransactionStatus(req model.BulkUpdateTransactionStatusRequest, adminID *uint) (*model.BulkUpdateTransactionStatusResponse, error) {
+	if len(req.IDs) == 0 {
+		return nil, fmt.Errorf("%w: ids are required", utils.ErrInvalidBulkRequest)
+	}
+	if len(req.IDs) > maxBulkTransactions {
+		return nil, fmt.Errorf("%w: at most %d ids per request", utils.ErrInvalidBulkRequest, maxBulkTransactions)
+	}
+
+	seen := make(map[string]bool, len(req.IDs))
+	for _, id := range req.IDs {
+		if seen[id] {
+			return nil, fmt.Errorf("%w: duplicate id %s", utils.ErrInvalidBulkRequest, id)
+		}
+		seen[id] = true
+	}
+
+	status := strings.ToLower(req.Status)
+	update := model.UpdateTransactionStatusRequest{
+		Reason:    strings.TrimSpace(req.Reason),
+		AdminNote: req.AdminNote,
+		Comment:   req.Comment,
+	}
+	if requiresReason(status) && update.Reason == "" {
+		return nil, utils.ErrReasonRequired
+	}
+
+	response := &model.BulkUpdateTransactionStatusResponse{
+		Status:  status,
+		Atomic:  req.Atomic,
+		Results: make([]model.BulkUpdateTransactionStatusResult, 0, len(req.IDs)),
+	}
+
+	apply := func(service *TransactionService) {
+		for _, id := range req.IDs {
+			result := model.BulkUpdateTransactionStatusResult{ID: id}
+			updated, err := service.UpdateTransactionStatus(status, id, adminID, update)
+			if err != nil {
+				result.Error = err.Error()
+				response.Failed++
+			} else {
+				result.Success = true
+				result.Message = updated.Message
+				response.Succeeded++
+			}
+			response.Results = append(response.Results, result)
+		}
+	}
+
+	if !req.Atomic {
+		apply(s)
+		return response, nil
+	}
+
+	err := s.logRepository.Transaction(func(tx *gorm.DB) error {
+		apply(s.withTx(tx))
+		if response.Failed > 0 {
+			return errBulkRolledBack
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errBulkRolledBack) {
+		return nil, err
+	}
+
+	if response.Failed > 0 {
+		for i := range response.Results {
+			result := &response.Results[i]
+			if result.Success {
+				result.Success = false
+				result.Message = ""
+				result.Error = "not applied because another transaction in the request failed"
+			}
+		}
+		response.Failed = len(response.Results)
+		response.Succeeded = 0
+	}
+
+	return response, nil
+}
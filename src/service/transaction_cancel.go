@@ -0,0 +1,135 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+	"gtihub.com/raditsoic/telkom-storage-ms/src/database/repository"
+	"gtihub.com/raditsoic/telkom-storage-ms/src/model"
+	"gtihub.com/raditsoic/telkom-storage-ms/src/utils"
+)
+
+const cancelledByRequesterComment = "Cancelled by requester"
+
+// CancelTransaction lets a requester withdraw their own request while it is
+// still pending or approved, authorized by the tracking token issued at
+// creation. Stock reserved for it is released and the request stays in the
+// history as cancelled.
+func (s *TransactionService) CancelTransaction(uuidStr, token string, req model.CancelTransactionRequest) (*model.UpdateTransactionResponse, error) {
+	if token == "" {
+		return nil, utils.ErrTrackingTokenRequired
+	}
+
+	transactionType, uuid, err := parseTransactionID(uuidStr)
+	if err != nil {
+		return nil, err
+	}
+
+	reason := strings.TrimSpace(req.Reason)
+
+	err = s.logRepository.Transaction(func(tx *gorm.DB) error {
+		logRepository := s.logRepository.WithTx(tx)
+		itemRepository := s.itemRepository.WithTx(tx).WithSource(uuidStr, nil)
+
+		switch transactionType {
+		case "loan":
+			loan, err := logRepository.GetLoanTransactionByUUIDForUpdate(uuid)
+			if err != nil {
+				return utils.ErrTransactionNotFound
+			}
+			if err := checkTrackingToken(loan.TrackingTokenHash, token); err != nil {
+				return err
+			}
+			if !isCancellable(loan.Status) {
+				return &utils.TransitionError{TransactionType: "loan", From: loan.Status, To: StatusCancelled}
+			}
+
+			if err := cancelLines(logRepository, itemRepository, "loan", loan.ID); err != nil {
+				return err
+			}
+			if err := recordStatusChange(logRepository, "loan", loan.UUID, nil, loan.Status, StatusCancelled, nil, reason, cancelledByRequesterComment); err != nil {
+				return err
+			}
+
+			loan.Status = StatusCancelled
+			loan.StatusReason = reason
+			if err := logRepository.UpdateLoanTransaction(loan); err != nil {
+				return fmt.Errorf("failed to update loan transaction: %w", err)
+			}
+		case "inquiry":
+			inquiry, err := logRepository.GetInquiryTransactionByUUIDForUpdate(uuid)
+			if err != nil {
+				return utils.ErrTransactionNotFound
+			}
+			if err := checkTrackingToken(inquiry.TrackingTokenHash, token); err != nil {
+				return err
+			}
+			if !isCancellable(inquiry.Status) {
+				return &utils.TransitionError{TransactionType: "inquiry", From: inquiry.Status, To: StatusCancelled}
+			}
+
+			if err := cancelLines(logRepository, itemRepository, "inquiry", inquiry.ID); err != nil {
+				return err
+			}
+			if err := recordStatusChange(logRepository, "inquiry", inquiry.UUID, nil, inquiry.Status, StatusCancelled, nil, reason, cancelledByRequesterComment); err != nil {
+				return err
+			}
+
+			inquiry.Status = StatusCancelled
+			inquiry.StatusReason = reason
+			if err := logRepository.UpdateInquiryTransaction(inquiry); err != nil {
+				return fmt.Errorf("failed to update inquiry transaction: %w", err)
+			}
+		case "insert":
+			insertion, err := logRepository.GetInsertionTransactionByUUIDForUpdate(uuid)
+			if err != nil {
+				return utils.ErrTransactionNotFound
+			}
+			if err := checkTrackingToken(insertion.TrackingTokenHash, token); err != nil {
+				return err
+			}
+			if !isCancellable(insertion.Status) {
+				return &utils.TransitionError{TransactionType: "insert", From: insertion.Status, To: StatusCancelled}
+			}
+
+			if err := recordStatusChange(logRepository, "insert", insertion.UUID, nil, insertion.Status, StatusCancelled, nil, reason, cancelledByRequesterComment); err != nil {
+				return err
+			}
+
+			insertion.Status = StatusCancelled
+			insertion.StatusReason = reason
+			if err := logRepository.UpdateInsertionTransaction(insertion); err != nil {
+				return fmt.Errorf("failed to update insertion transaction: %w", err)
+			}
+		default:
+			return utils.ErrTransactionType
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.UpdateTransactionResponse{
+		Message: "Transaction cancelled successfully",
+		ID:      uuid.String(),
+	}, nil
+}
+
+// cancelLines cancels the undecided lines of a loan or inquiry and releases
+// the stock reserved for approved ones. A request with a line that was
+// already handed out can no longer be cancelled.
+func cancelLines(logRepository *repository.TransactionRepository, itemRepository *repository.ItemRepository, transactionType string, transactionID uint) error {
+	lines, err := logRepository.GetTransactionLinesForUpdate(transactionType, transactionID)
+	if err != nil {
+		return err
+	}
+
+	if err := checkLinesNotHandedOut(transactionType, lines, StatusCancelled); err != nil {
+		return err
+	}
+
+	return applyStatusToLines(logRepository, itemRepository, lines, StatusCancelled)
+}
@@ -0,0 +1,65 @@
+package service
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gtihub.com/raditsoic/telkom-storage-ms/src/database/repository"
+	"gtihub.com/raditsoic/telkom-storage-ms/src/model"
+	"gtihub.com/raditsoic/telkom-storage-ms/src/utils"
+)
+
+// recordStatusChange adds a status change to the timeline of a transaction.
+// Changes that leave the status as it was are skipped.
+func recordStatusChange(logRepository *repository.TransactionRepository, transactionType string, transactionUUID uuid.UUID, lineID *uint, from, to string, adminID *uint, reason, comment string) error {
+	if from == to {
+		return nil
+	}
+
+	return logRepository.CreateTransactionEvent(&model.TransactionEvent{
+		TransactionType: transactionType,
+		TransactionUUID: transactionUUID,
+		LineID:          lineID,
+		FromStatus:      from,
+		ToStatus:        to,
+		AdminID:         adminID,
+		Reason:          reason,
+		Comment:         comment,
+		Time:            time.Now(),
+	})
+}
+
+// GetTransactionHistory returns every recorded status change of a transaction,
+// oldest first.
+func (s *TransactionService) GetTransactionHistory(uuidStr string) (*model.TransactionHistoryResponse, error) {
+	transactionType, uuid, err := parseTransactionID(uuidStr)
+	if err != nil {
+		return nil, err
+	}
+
+	switch transactionType {
+	case "loan":
+		_, err = s.logRepository.GetLoanTransactionByUUID(uuid)
+	case "inquiry":
+		_, err = s.logRepository.GetInquiryTransactionByUUID(uuid)
+	case "insert":
+		_, err = s.logRepository.GetInsertionTransactionByUUID(uuid)
+	case "transfer":
+		_, err = s.logRepository.GetTransferTransactionByUUID(uuid)
+	default:
+		return nil, utils.ErrTransactionType
+	}
+	if err != nil {
+		return nil, utils.ErrTransactionNotFound
+	}
+
+	events, err := s.logRepository.GetTransactionEvents(transactionType, uuid)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.TransactionHistoryResponse{
+		ID:     uuidStr,
+		Events: events,
+	}, nil
+}
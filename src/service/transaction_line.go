@@ -0,0 +1,505 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gtihub.com/raditsoic/telkom-storage-ms/src/database/repository"
+	"gtihub.com/raditsoic/telkom-storage-ms/src/model"
+	"gtihub.com/raditsoic/telkom-storage-ms/src/utils"
+)
+
+// lineTransitions lists the statuses a single line of a loan or inquiry can be
+// moved to by an admin. Returning loan lines goes through the return flow.
+var lineTransitions = map[string][]string{
+	StatusPending:  {StatusApproved, StatusRejected},
+	StatusApproved: {StatusCompleted, StatusRejected},
+}
+
+func allowedLineTransitions(from string) []string {
+	next := lineTransitions[from]
+	if next == nil {
+		return []string{}
+	}
+
+	return next
+}
+
+func checkLineTransition(transactionType, from, to string) error {
+	for _, next := range allowedLineTransitions(from) {
+		if next == to {
+			return nil
+		}
+	}
+
+	if !isKnownStatus(transactionType, to) {
+		return utils.ErrInvalidStatus
+	}
+
+	return &utils.TransitionError{TransactionType: transactionType + " line", From: from, To: to}
+}
+
+// isOpenStatus reports whether lines of a request in the given status can
+// still be decided individually.
+func isOpenStatus(status string) bool {
+	return status == StatusPending || status == StatusIncomplete || status == StatusApproved
+}
+
+// prepareLines validates the requested lines of a new loan or inquiry. A
+// request without lines is treated as a single line built from its item ID
+// and quantity. Items of a storage that is being counted cannot be requested.
+// It returns the lines, their items and the total quantity.
+func (s *TransactionService) prepareLines(itemID *uint, quantity int, requested []model.TransactionLine) ([]model.TransactionLine, []*model.Item, int, error) {
+	if len(requested) == 0 {
+		if itemID == nil {
+			return nil, nil, 0, utils.ErrItemNotFound
+		}
+		requested = []model.TransactionLine{{ItemID: *itemID, Quantity: quantity}}
+	}
+
+	lines := make([]model.TransactionLine, 0, len(requested))
+	items := make([]*model.Item, 0, len(requested))
+	total := 0
+	for _, line := range requested {
+		if line.Quantity <= 0 {
+			return nil, nil, 0, utils.ErrInvalidQuantity
+		}
+
+		item, err := s.itemRepository.GetItemByID(fmt.Sprintf("%d", line.ItemID))
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, nil, 0, fmt.Errorf("item with ID %d not found: %w", line.ItemID, utils.ErrItemNotFound)
+			}
+			return nil, nil, 0, fmt.Errorf("error fetching item: %w", err)
+		}
+
+		lines = append(lines, model.TransactionLine{
+			ItemID:   line.ItemID,
+			Quantity: line.Quantity,
+			Status:   StatusPending,
+		})
+		items = append(items, item)
+		total += line.Quantity
+	}
+
+	if err := checkNotCounting(&s.opnameRepository, lineItemIDs(lines, StatusPending)); err != nil {
+		return nil, nil, 0, err
+	}
+
+	return lines, items, total, nil
+}
+
+// attachLineItems fills in the items of freshly created lines for the
+// creation response.
+func attachLineItems(lines []model.TransactionLine, items []*model.Item) []model.TransactionLine {
+	for i := range lines {
+		lines[i].Item = items[i]
+	}
+
+	return lines
+}
+
+// moveLine moves one locked line to a new status and applies its stock effect.
+func moveLine(logRepository *repository.TransactionRepository, itemRepository *repository.ItemRepository, line *model.TransactionLine, to string) error {
+	if err := moveReservedStock(itemRepository, line.ItemID, line.Quantity, line.Status, to, line.TransactionType); err != nil {
+		return fmt.Errorf("failed to update stock of item %d: %w", line.ItemID, err)
+	}
+
+	line.Status = to
+	if to == StatusCompleted {
+		now := time.Now()
+		line.CompletedTime = &now
+	}
+
+	return logRepository.UpdateTransactionLine(line)
+}
+
+// checkLinesNotHandedOut refuses to reject, reopen or cancel a request with a
+// line that was already handed out, since the stock of that line could then
+// never be returned.
+func checkLinesNotHandedOut(transactionType string, lines []model.TransactionLine, status string) error {
+	if status != StatusRejected && status != StatusIncomplete && status != StatusCancelled {
+		return nil
+	}
+
+	for _, line := range lines {
+		if line.Status == StatusCompleted || line.Status == StatusReturned {
+			return &utils.TransitionError{TransactionType: transactionType + " line", From: line.Status, To: status}
+		}
+	}
+
+	return nil
+}
+
+// applyStatusToLines carries a request-wide status change over to its lines.
+// Lines already decided individually are left alone, and any stock failure
+// aborts the whole change.
+func applyStatusToLines(logRepository *repository.TransactionRepository, itemRepository *repository.ItemRepository, lines []model.TransactionLine, status string) error {
+	for i := range lines {
+		line := &lines[i]
+
+		var to string
+		switch {
+		case status == StatusApproved && line.Status == StatusPending:
+			to = StatusApproved
+		case status == StatusIncomplete && line.Status == StatusApproved:
+			to = StatusPending
+		case status == StatusRejected && (line.Status == StatusPending || line.Status == StatusApproved):
+			to = StatusRejected
+		case status == StatusCompleted && line.Status == StatusApproved:
+			to = StatusCompleted
+		case status == StatusCancelled && (line.Status == StatusPending || line.Status == StatusApproved):
+			to = StatusCancelled
+		default:
+			continue
+		}
+
+		if err := moveLine(logRepository, itemRepository, line, to); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// deriveStatus works out the request-wide status implied by its lines after
+// one of them was decided individually.
+func deriveStatus(current string, lines []model.TransactionLine) string {
+	var approved, completed, rejected int
+	for _, line := range lines {
+		switch line.Status {
+		case StatusApproved:
+			approved++
+		case StatusCompleted, StatusReturned:
+			completed++
+		case StatusRejected:
+			rejected++
+		}
+	}
+
+	switch {
+	case rejected == len(lines):
+		return StatusRejected
+	case completed > 0 && completed+rejected == len(lines):
+		return StatusCompleted
+	case approved > 0 && approved+completed+rejected == len(lines):
+		return StatusApproved
+	}
+
+	return current
+}
+
+// decideLine moves a single line of an open loan or inquiry and returns the
+// request-wide status its lines now imply. A line cannot be handed out while
+// its item is being counted.
+func decideLine(logRepository *repository.TransactionRepository, itemRepository *repository.ItemRepository, opnameRepository *repository.StockOpnameRepository, transactionType string, transactionID uint, transactionUUID uuid.UUID, current string, lineID uint, status string, adminID *uint, req model.UpdateTransactionStatusRequest) (string, error) {
+	if !isOpenStatus(current) {
+		return "", &utils.TransitionError{TransactionType: transactionType, From: current, To: status}
+	}
+
+	lines, err := logRepository.GetTransactionLinesForUpdate(transactionType, transactionID)
+	if err != nil {
+		return "", err
+	}
+
+	var line *model.TransactionLine
+	for i := range lines {
+		if lines[i].ID == lineID {
+			line = &lines[i]
+		}
+	}
+	if line == nil {
+		return "", utils.ErrLineNotFound
+	}
+
+	if err := checkLineTransition(transactionType, line.Status, status); err != nil {
+		return "", err
+	}
+	if status == StatusCompleted {
+		if err := checkNotCounting(opnameRepository, []uint{line.ItemID}); err != nil {
+			return "", err
+		}
+	}
+	if err := recordStatusChange(logRepository, transactionType, transactionUUID, &line.ID, line.Status, status, adminID, req.Reason, req.Comment); err != nil {
+		return "", err
+	}
+	if err := moveLine(logRepository, itemRepository, line, status); err != nil {
+		return "", err
+	}
+
+	derived := deriveStatus(current, lines)
+	if err := recordStatusChange(logRepository, transactionType, transactionUUID, nil, current, derived, adminID, req.Reason, req.Comment); err != nil {
+		return "", err
+	}
+
+	return derived, nil
+}
+
+// UpdateTransactionLineStatus decides a single line of a multi-item loan or
+// inquiry, keeping the request-wide status in step with its lines.
+func (s *TransactionService) UpdateTransactionLineStatus(status, uuidStr, lineIDStr string, adminID *uint, req model.UpdateTransactionStatusRequest) (*model.UpdateTransactionResponse, error) {
+	transactionType, uuid, err := parseTransactionID(uuidStr)
+	if err != nil {
+		return nil, err
+	}
+
+	lineID, err := strconv.ParseUint(lineIDStr, 10, 32)
+	if err != nil {
+		return nil, utils.ErrInvalidID
+	}
+
+	status = strings.ToLower(status)
+
+	req.Reason = strings.TrimSpace(req.Reason)
+	if requiresReason(status) && req.Reason == "" {
+		return nil, utils.ErrReasonRequired
+	}
+
+	err = s.logRepository.Transaction(func(tx *gorm.DB) error {
+		logRepository := s.logRepository.WithTx(tx)
+		itemRepository := s.itemRepository.WithTx(tx).WithSource(uuidStr, adminID)
+		opnameRepository := s.opnameRepository.WithTx(tx)
+
+		switch transactionType {
+		case "loan":
+			loan, err := logRepository.GetLoanTransactionByUUIDForUpdate(uuid)
+			if err != nil {
+				return utils.ErrTransactionNotFound
+			}
+
+			if status == StatusApproved {
+				if err := checkApprovalChain(logRepository, "loan", loan.ID); err != nil {
+					return err
+				}
+			}
+
+			derived, err := decideLine(logRepository, itemRepository, opnameRepository, "loan", loan.ID, loan.UUID, loan.Status, uint(lineID), status, adminID, req)
+			if err != nil {
+				return err
+			}
+			if derived == StatusCompleted && loan.Status != StatusCompleted {
+				now := time.Now()
+				loan.CompletedTime = &now
+			}
+			if derived != loan.Status {
+				loan.StatusReason = req.Reason
+			}
+			loan.Status = derived
+			if req.AdminNote != "" {
+				loan.AdminNote = req.AdminNote
+			}
+
+			return logRepository.UpdateLoanTransaction(loan)
+		case "inquiry":
+			inquiry, err := logRepository.GetInquiryTransactionByUUIDForUpdate(uuid)
+			if err != nil {
+				return utils.ErrTransactionNotFound
+			}
+
+			if status == StatusApproved {
+				if err := checkApprovalChain(logRepository, "inquiry", inquiry.ID); err != nil {
+					return err
+				}
+			}
+
+			derived, err := decideLine(logRepository, itemRepository, opnameRepository, "inquiry", inquiry.ID, inquiry.UUID, inquiry.Status, uint(lineID), status, adminID, req)
+			if err != nil {
+				return err
+			}
+			if derived == StatusCompleted && inquiry.Status != StatusCompleted {
+				now := time.Now()
+				inquiry.CompletedTime = &now
+			}
+			if derived != inquiry.Status {
+				inquiry.StatusReason = req.Reason
+			}
+			inquiry.Status = derived
+			if req.AdminNote != "" {
+				inquiry.AdminNote = req.AdminNote
+			}
+
+			return logRepository.UpdateInquiryTransaction(inquiry)
+		default:
+			return utils.ErrTransactionType
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.UpdateTransactionResponse{
+		Message: fmt.Sprintf("Transaction line %s successfully", status),
+		ID:      uuid.String(),
+	}, nil
+}
+
+// Conditions of returned loan units that are written off instead of going
+// back to stock.
+const (
+	ReturnConditionDamaged = "damaged"
+	ReturnConditionLost    = "lost"
+)
+
+// returnLineQuantity settles quantity units of a completed loan line, of
+// which damaged came back damaged and lost did not come back. Only the good
+// units are restocked; the others are written off against the borrower.
+func returnLineQuantity(logRepository *repository.TransactionRepository, itemRepository *repository.ItemRepository, loan *model.LoanTransaction, line *model.TransactionLine, quantity, damaged, lost int, adminID *uint) error {
+	if line.Status != StatusCompleted {
+		return &utils.TransitionError{TransactionType: "loan line", From: line.Status, To: StatusReturned}
+	}
+	if quantity <= 0 || quantity > line.Quantity-line.ReturnedQuantity {
+		return utils.ErrInvalidReturnQuantity
+	}
+	if damaged < 0 || lost < 0 || damaged+lost > quantity {
+		return utils.ErrInvalidReturnCondition
+	}
+	good := quantity - damaged - lost
+
+	if _, err := itemRepository.GetItemByIDForUpdate(line.ItemID); err != nil {
+		return utils.ErrItemNotFound
+	}
+	if good > 0 {
+		if err := itemRepository.AdjustItemQuantity(line.ItemID, good, MovementLoanReturn); err != nil {
+			return fmt.Errorf("failed to update item quantity: %w", err)
+		}
+	}
+
+	now := time.Now()
+	lineID := line.ID
+	loanReturn := &model.LoanReturn{
+		LoanTransactionID: loan.ID,
+		TransactionLineID: &lineID,
+		Quantity:          quantity,
+		Good:              good,
+		Damaged:           damaged,
+		Lost:              lost,
+		Time:              now,
+	}
+	if err := logRepository.CreateLoanReturn(loanReturn); err != nil {
+		return err
+	}
+
+	var writeOffs []model.WriteOff
+	for _, writeOff := range []struct {
+		condition string
+		quantity  int
+	}{{ReturnConditionDamaged, damaged}, {ReturnConditionLost, lost}} {
+		if writeOff.quantity == 0 {
+			continue
+		}
+		writeOffs = append(writeOffs, model.WriteOff{
+			LoanTransactionID:  loan.ID,
+			LoanReturnID:       loanReturn.ID,
+			TransactionLineID:  &lineID,
+			ItemID:             line.ItemID,
+			EmployeeID:         loan.EmployeeID,
+			EmployeeName:       loan.EmployeeName,
+			EmployeeDepartment: loan.EmployeeDepartment,
+			Condition:          writeOff.condition,
+			Quantity:           writeOff.quantity,
+			AdminID:            adminID,
+			Time:               now,
+		})
+	}
+	if err := logRepository.CreateWriteOffs(writeOffs); err != nil {
+		return err
+	}
+
+	line.ReturnedQuantity += quantity
+	if line.ReturnedQuantity == line.Quantity {
+		line.Status = StatusReturned
+	}
+	if err := logRepository.UpdateTransactionLine(line); err != nil {
+		return err
+	}
+
+	loan.ReturnedQuantity += quantity
+	return nil
+}
+
+// returnConditions matches the conditions of a whole-loan return to the
+// lines still out. A condition without a line applies to the only line out.
+func returnConditions(conditions []model.ReturnCondition, lines []model.TransactionLine) (map[uint]model.ReturnCondition, error) {
+	var out []uint
+	for _, line := range lines {
+		if line.Status == StatusCompleted {
+			out = append(out, line.ID)
+		}
+	}
+
+	matched := make(map[uint]model.ReturnCondition, len(conditions))
+	for _, condition := range conditions {
+		lineID := condition.LineID
+		if lineID == 0 {
+			if len(out) != 1 {
+				return nil, utils.ErrLineNotFound
+			}
+			lineID = out[0]
+		}
+
+		found := false
+		for _, id := range out {
+			if id == lineID {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, utils.ErrLineNotFound
+		}
+		if _, ok := matched[lineID]; ok {
+			return nil, utils.ErrInvalidReturnCondition
+		}
+		matched[lineID] = condition
+	}
+
+	return matched, nil
+}
+
+// finishLoanReturn saves a loan after returns were recorded against its lines,
+// closing it once every handed-out unit is back.
+func finishLoanReturn(logRepository *repository.TransactionRepository, loan *model.LoanTransaction, lines []model.TransactionLine, adminID *uint, req model.UpdateTransactionStatusRequest) error {
+	settled := true
+	for _, line := range lines {
+		if line.Status != StatusReturned && line.Status != StatusRejected {
+			settled = false
+		}
+	}
+
+	if settled {
+		if err := recordStatusChange(logRepository, "loan", loan.UUID, nil, loan.Status, StatusReturned, adminID, req.Reason, req.Comment); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		loan.Status = StatusReturned
+		loan.StatusReason = req.Reason
+		loan.ReturnedTime = &now
+	}
+	if req.AdminNote != "" {
+		loan.AdminNote = req.AdminNote
+	}
+
+	if err := logRepository.UpdateLoanTransaction(loan); err != nil {
+		return fmt.Errorf("failed to update loan transaction: %w", err)
+	}
+
+	return nil
+}
+
+// outstandingQuantity counts the handed-out units of a loan that are not back.
+func outstandingQuantity(lines []model.TransactionLine) int {
+	outstanding := 0
+	for _, line := range lines {
+		if line.Status == StatusCompleted {
+			outstanding += line.Quantity - line.ReturnedQuantity
+		}
+	}
+
+	return outstanding
+}
@@ -0,0 +1,78 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"gtihub.com/raditsoic/telkom-storage-ms/src/model"
+	"gtihub.com/raditsoic/telkom-storage-ms/src/utils"
+)
+
+func linesWithStatuses(statuses ...string) []model.TransactionLine {
+	lines := make([]model.TransactionLine, 0, len(statuses))
+	for i, status := range statuses {
+		lines = append(lines, model.TransactionLine{ID: uint(i + 1), Quantity: 1, Status: status})
+	}
+
+	return lines
+}
+
+func TestDeriveStatus(t *testing.T) {
+	tests := []struct {
+		name    string
+		current string
+		lines   []model.TransactionLine
+		want    string
+	}{
+		{"all pending keeps current", StatusPending, linesWithStatuses(StatusPending, StatusPending), StatusPending},
+		{"some approved keeps current", StatusPending, linesWithStatuses(StatusApproved, StatusPending), StatusPending},
+		{"all approved", StatusPending, linesWithStatuses(StatusApproved, StatusApproved), StatusApproved},
+		{"approved and rejected", StatusPending, linesWithStatuses(StatusApproved, StatusRejected), StatusApproved},
+		{"all rejected", StatusApproved, linesWithStatuses(StatusRejected, StatusRejected), StatusRejected},
+		{"all completed", StatusApproved, linesWithStatuses(StatusCompleted, StatusCompleted), StatusCompleted},
+		{"completed and rejected", StatusApproved, linesWithStatuses(StatusCompleted, StatusRejected), StatusCompleted},
+		{"returned counts as completed", StatusApproved, linesWithStatuses(StatusReturned, StatusCompleted), StatusCompleted},
+		{"completed and still approved", StatusApproved, linesWithStatuses(StatusCompleted, StatusApproved), StatusApproved},
+		{"completed and still pending keeps current", StatusApproved, linesWithStatuses(StatusCompleted, StatusPending), StatusApproved},
+		{"single rejected line", StatusPending, linesWithStatuses(StatusRejected), StatusRejected},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := deriveStatus(tt.current, tt.lines); got != tt.want {
+				t.Fatalf("deriveStatus(%q, lines) = %q, want %q", tt.current, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckLinesNotHandedOut(t *testing.T) {
+	tests := []struct {
+		name   string
+		lines  []model.TransactionLine
+		status string
+		want   error
+	}{
+		{"reject undecided lines", linesWithStatuses(StatusPending, StatusApproved), StatusRejected, nil},
+		{"reject with a completed line", linesWithStatuses(StatusCompleted, StatusApproved), StatusRejected, utils.ErrInvalidTransition},
+		{"reopen with a completed line", linesWithStatuses(StatusApproved, StatusCompleted), StatusIncomplete, utils.ErrInvalidTransition},
+		{"cancel with a returned line", linesWithStatuses(StatusReturned, StatusPending), StatusCancelled, utils.ErrInvalidTransition},
+		{"complete the rest", linesWithStatuses(StatusCompleted, StatusApproved), StatusCompleted, nil},
+		{"return handed out lines", linesWithStatuses(StatusCompleted, StatusReturned), StatusReturned, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkLinesNotHandedOut("loan", tt.lines, tt.status)
+			if tt.want == nil {
+				if err != nil {
+					t.Fatalf("checkLinesNotHandedOut(%q) = %v, want nil", tt.status, err)
+				}
+				return
+			}
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("checkLinesNotHandedOut(%q) = %v, want %v", tt.status, err, tt.want)
+			}
+		})
+	}
+}
@@ -1,7 +1,8 @@
 package service
 
 import (
-	"errors"
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"strings"
 	"time"
@@ -14,114 +15,280 @@ import (
 )
 
 type TransactionService struct {
-	logRepository  repository.TransactionRepository
-	itemRepository repository.ItemRepository
+	logRepository      repository.TransactionRepository
+	itemRepository     repository.ItemRepository
+	opnameRepository   repository.StockOpnameRepository
+	employeeRepository repository.EmployeeRepository
+	quotaRepository    repository.QuotaRepository
+	ruleRepository     repository.ApprovalRuleRepository
+	chainRepository    repository.ApprovalChainRepository
+	categoryRepository repository.CategoryRepository
 }
 
-func NewTransactionService(log repository.TransactionRepository, item repository.ItemRepository) *TransactionService {
-	return &TransactionService{logRepository: log, itemRepository: item}
+func NewTransactionService(log repository.TransactionRepository, item repository.ItemRepository, opname repository.StockOpnameRepository, employee repository.EmployeeRepository, quota repository.QuotaRepository, rule repository.ApprovalRuleRepository, chain repository.ApprovalChainRepository, category repository.CategoryRepository) *TransactionService {
+	return &TransactionService{logRepository: log, itemRepository: item, opnameRepository: opname, employeeRepository: employee, quotaRepository: quota, ruleRepository: rule, chainRepository: chain, categoryRepository: category}
 }
 
-func (s *TransactionService) GetTransactions(page, limit int) ([]model.GetAllTransactionsResponse, error) {
-	var transactions []model.GetAllTransactionsResponse
-	offset := (page - 1) * limit
+// GetTransactions lists the transactions of every type matching the filter
+// as a single feed, along with how many there are in total. Pages are picked
+// either by cursor or, without one, by page number.
+func (s *TransactionService) GetTransactions(filter model.TransactionFilter, cursor string, page, limit int) (*model.GetTransactionsResponse, error) {
+	switch filter.Type {
+	case "", "loan", "inquiry", "insert", "transfer":
+	default:
+		return nil, utils.ErrTransactionType
+	}
+	if filter.Sort != "" && filter.Sort != "time" && filter.Sort != "status" && filter.Sort != "employee" {
+		return nil, utils.ErrInvalidSort
+	}
+
+	response := &model.GetTransactionsResponse{
+		Transactions: []model.GetAllTransactionsResponse{},
+		Limit:        limit,
+	}
+
+	var after *model.TransactionCursor
+	offset := 0
+	if cursor != "" {
+		var err error
+		after, err = decodeTransactionCursor(cursor, filter)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		response.Page = page
+		offset = (page - 1) * limit
+	}
+
+	total, err := s.logRepository.CountTransactionFeed(filter)
+	if err != nil {
+		return nil, err
+	}
+	response.Total = total
 
-	loanTransactions, err := s.logRepository.GetLoanTransactions(limit, offset)
+	// One extra entry tells whether another page follows.
+	entries, err := s.logRepository.GetTransactionFeed(filter, after, limit+1, offset)
 	if err != nil {
 		return nil, err
 	}
 
-	for _, loan := range loanTransactions {
-		customUUID := fmt.Sprintf("%s_%s", "loan", loan.UUID)
+	if len(entries) > limit {
+		entries = entries[:limit]
+		response.NextCursor = encodeTransactionCursor(filter, entries[len(entries)-1])
+	}
 
-		var itemRequest model.ItemRequestDTO
-		if loan.Item != nil {
-			itemRequest = model.ItemRequestDTO{
-				Name:       loan.Item.Name,
-				Quantity:   loan.Quantity,
-				Shelf:      loan.Item.Shelf,
-				CategoryID: loan.Item.CategoryID,
-			}
+	ids := map[string][]uint{}
+	for _, entry := range entries {
+		ids[entry.TransactionType] = append(ids[entry.TransactionType], entry.ID)
+	}
+
+	transactions := map[string]model.GetAllTransactionsResponse{}
+	if len(ids["loan"]) > 0 {
+		loans, err := s.logRepository.GetLoanTransactionsByIDs(ids["loan"])
+		if err != nil {
+			return nil, err
+		}
+		for _, loan := range loans {
+			transactions[fmt.Sprintf("loan_%d", loan.ID)] = loanTransactionResponse(loan)
+		}
+	}
+	if len(ids["inquiry"]) > 0 {
+		inquiries, err := s.logRepository.GetInquiryTransactionsByIDs(ids["inquiry"])
+		if err != nil {
+			return nil, err
+		}
+		for _, inquiry := range inquiries {
+			transactions[fmt.Sprintf("inquiry_%d", inquiry.ID)] = inquiryTransactionResponse(inquiry)
+		}
+	}
+	if len(ids["insert"]) > 0 {
+		insertions, err := s.logRepository.GetInsertionTransactionsByIDs(ids["insert"])
+		if err != nil {
+			return nil, err
+		}
+		for _, insertion := range insertions {
+			transactions[fmt.Sprintf("insert_%d", insertion.ID)] = insertionTransactionResponse(insertion)
 		}
+	}
+	if len(ids["transfer"]) > 0 {
+		transfers, err := s.logRepository.GetTransferTransactionsByIDs(ids["transfer"])
+		if err != nil {
+			return nil, err
+		}
+		for _, transfer := range transfers {
+			transactions[fmt.Sprintf("transfer_%d", transfer.ID)] = transferTransactionResponse(transfer)
+		}
+	}
 
-		transaction := model.GetAllTransactionsResponse{
-			UUID:               customUUID,
-			TransactionType:    loan.TransactionType,
-			EmployeeName:       loan.EmployeeName,
-			EmployeeDepartment: loan.EmployeeDepartment,
-			EmployeePosition:   loan.EmployeePosition,
-			Quantity:           loan.Quantity,
-			Status:             loan.Status,
-			Notes:              loan.Notes,
-			Time:               loan.Time,
-			LoanTime:           &loan.LoanTime,
-			ReturnTime:         &loan.ReturnTime,
-			ItemRequest:        &itemRequest,
-			CompletedTime:      loan.CompletedTime,
-			ReturnedTime:       loan.ReturnedTime,
+	for _, entry := range entries {
+		if transaction, ok := transactions[fmt.Sprintf("%s_%d", entry.TransactionType, entry.ID)]; ok {
+			response.Transactions = append(response.Transactions, transaction)
 		}
+	}
+
+	return response, nil
+}
 
-		transactions = append(transactions, transaction)
+// transactionFeedOrder returns the sort field and direction the feed is
+// listed in, with the defaults filled in.
+func transactionFeedOrder(filter model.TransactionFilter) (string, string) {
+	sort, order := filter.Sort, "desc"
+	if sort == "" {
+		sort = "time"
 	}
+	if filter.Order == "asc" {
+		order = "asc"
+	}
+
+	return sort, order
+}
+
+// encodeTransactionCursor builds the opaque cursor pointing past entry. It
+// records the order of the listing, since the position means nothing in
+// another one.
+func encodeTransactionCursor(filter model.TransactionFilter, entry model.TransactionFeedEntry) string {
+	cursor := model.TransactionCursor{
+		Type: entry.TransactionType,
+		ID:   entry.ID,
+		Time: entry.Time,
+	}
+	cursor.Sort, cursor.Order = transactionFeedOrder(filter)
+	switch filter.Sort {
+	case "status":
+		cursor.Value = entry.Status
+	case "employee":
+		cursor.Value = entry.EmployeeName
+	}
+
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
 
-	inquiryTransactions, err := s.logRepository.GetInquiryTransactions(limit, offset)
+// decodeTransactionCursor reads a cursor, refusing one that was issued for a
+// listing in another order.
+func decodeTransactionCursor(cursor string, filter model.TransactionFilter) (*model.TransactionCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
 	if err != nil {
-		return nil, err
+		return nil, utils.ErrInvalidCursor
 	}
-	for _, inquiry := range inquiryTransactions {
-		customUUID := fmt.Sprintf("%s_%s", "inquiry", inquiry.UUID)
 
-		var itemRequest model.ItemRequestDTO
-		if inquiry.Item != nil {
-			itemRequest = model.ItemRequestDTO{
-				Name:       inquiry.Item.Name,
-				Quantity:   inquiry.Quantity,
-				Shelf:      inquiry.Item.Shelf,
-				CategoryID: inquiry.Item.CategoryID,
-			}
-		}
+	var decoded model.TransactionCursor
+	if err := json.Unmarshal(data, &decoded); err != nil || decoded.Type == "" {
+		return nil, utils.ErrInvalidCursor
+	}
 
-		transaction := model.GetAllTransactionsResponse{
-			UUID:               customUUID,
-			TransactionType:    inquiry.TransactionType,
-			EmployeeName:       inquiry.EmployeeName,
-			EmployeeDepartment: inquiry.EmployeeDepartment,
-			EmployeePosition:   inquiry.EmployeePosition,
-			Quantity:           inquiry.Quantity,
-			Status:             inquiry.Status,
-			Time:               inquiry.Time,
-			Notes:              inquiry.Notes,
-			ItemRequest:        &itemRequest,
-			CompletedTime:      inquiry.CompletedTime,
-		}
+	sort, order := transactionFeedOrder(filter)
+	if decoded.Sort != sort || decoded.Order != order {
+		return nil, fmt.Errorf("%w: it was issued for sort=%s&order=%s", utils.ErrCursorOrderMismatch, decoded.Sort, decoded.Order)
+	}
+
+	return &decoded, nil
+}
 
-		transactions = append(transactions, transaction)
+// deletedTime returns when a transaction was moved to the trash, or nil.
+func deletedTime(deletedAt gorm.DeletedAt) *time.Time {
+	if !deletedAt.Valid {
+		return nil
 	}
 
-	insertionTransactions, err := s.logRepository.GetInsertionTransactions(limit, offset)
-	if err != nil {
-		return nil, err
+	return &deletedAt.Time
+}
+
+func loanTransactionResponse(loan model.LoanTransaction) model.GetAllTransactionsResponse {
+	var itemRequest model.ItemRequestDTO
+	if loan.Item != nil {
+		itemRequest = model.ItemRequestDTO{
+			Name:       loan.Item.Name,
+			Quantity:   loan.Quantity,
+			Shelf:      loan.Item.Shelf,
+			CategoryID: loan.Item.CategoryID,
+		}
+	}
+
+	return model.GetAllTransactionsResponse{
+		UUID:                fmt.Sprintf("%s_%s", "loan", loan.UUID),
+		TransactionType:     loan.TransactionType,
+		EmployeeName:        loan.EmployeeName,
+		EmployeeDepartment:  loan.EmployeeDepartment,
+		EmployeePosition:    loan.EmployeePosition,
+		EmployeeID:          loan.EmployeeID,
+		Quantity:            loan.Quantity,
+		Status:              loan.Status,
+		Notes:               loan.Notes,
+		Time:                loan.Time,
+		LoanTime:            &loan.LoanTime,
+		ReturnTime:          &loan.ReturnTime,
+		ItemRequest:         &itemRequest,
+		CompletedTime:       loan.CompletedTime,
+		StatusReason:        loan.StatusReason,
+		AdminNote:           loan.AdminNote,
+		DeletedTime:         deletedTime(loan.DeletedAt),
+		DeletedBy:           loan.DeletedBy,
+		DeleteReason:        loan.DeleteReason,
+		ReturnedTime:        loan.ReturnedTime,
+		OverdueTime:         loan.OverdueTime,
+		AutoApprovalRuleID:  loan.AutoApprovalRuleID,
+		OutstandingQuantity: &loan.OutstandingQuantity,
+		Extensions:          loan.Extensions,
+		Lines:               loan.Lines,
 	}
-	for _, insertion := range insertionTransactions {
-		customUUID := fmt.Sprintf("%s_%s", "insert", insertion.UUID)
-		transaction := model.GetAllTransactionsResponse{
-			UUID:               customUUID,
-			TransactionType:    insertion.TransactionType,
-			EmployeeName:       insertion.EmployeeName,
-			EmployeeDepartment: insertion.EmployeeDepartment,
-			EmployeePosition:   insertion.EmployeePosition,
-			Status:             insertion.Status,
-			Time:               insertion.Time,
-			Notes:              insertion.Notes,
-			Image:              &insertion.Image,
-			ItemRequest:        &insertion.ItemRequest,
-			CompletedTime:      insertion.CompletedTime,
+}
+
+func inquiryTransactionResponse(inquiry model.InquiryTransaction) model.GetAllTransactionsResponse {
+	var itemRequest model.ItemRequestDTO
+	if inquiry.Item != nil {
+		itemRequest = model.ItemRequestDTO{
+			Name:       inquiry.Item.Name,
+			Quantity:   inquiry.Quantity,
+			Shelf:      inquiry.Item.Shelf,
+			CategoryID: inquiry.Item.CategoryID,
 		}
+	}
 
-		transactions = append(transactions, transaction)
+	return model.GetAllTransactionsResponse{
+		UUID:               fmt.Sprintf("%s_%s", "inquiry", inquiry.UUID),
+		TransactionType:    inquiry.TransactionType,
+		EmployeeName:       inquiry.EmployeeName,
+		EmployeeDepartment: inquiry.EmployeeDepartment,
+		EmployeePosition:   inquiry.EmployeePosition,
+		EmployeeID:         inquiry.EmployeeID,
+		Quantity:           inquiry.Quantity,
+		Status:             inquiry.Status,
+		Time:               inquiry.Time,
+		Notes:              inquiry.Notes,
+		ItemRequest:        &itemRequest,
+		CompletedTime:      inquiry.CompletedTime,
+		StatusReason:       inquiry.StatusReason,
+		AdminNote:          inquiry.AdminNote,
+		DeletedTime:        deletedTime(inquiry.DeletedAt),
+		DeletedBy:          inquiry.DeletedBy,
+		DeleteReason:       inquiry.DeleteReason,
+		QuotaExceeded:      inquiry.QuotaExceeded,
+		AutoApprovalRuleID: inquiry.AutoApprovalRuleID,
+		Lines:              inquiry.Lines,
 	}
+}
 
-	return transactions, nil
+func insertionTransactionResponse(insertion model.InsertionTransaction) model.GetAllTransactionsResponse {
+	return model.GetAllTransactionsResponse{
+		UUID:               fmt.Sprintf("%s_%s", "insert", insertion.UUID),
+		TransactionType:    insertion.TransactionType,
+		EmployeeName:       insertion.EmployeeName,
+		EmployeeDepartment: insertion.EmployeeDepartment,
+		EmployeePosition:   insertion.EmployeePosition,
+		EmployeeID:         insertion.EmployeeID,
+		Status:             insertion.Status,
+		Time:               insertion.Time,
+		Notes:              insertion.Notes,
+		Image:              &insertion.Image,
+		ItemRequest:        &insertion.ItemRequest,
+		CompletedTime:      insertion.CompletedTime,
+		StatusReason:       insertion.StatusReason,
+		AdminNote:          insertion.AdminNote,
+		DeletedTime:        deletedTime(insertion.DeletedAt),
+		DeletedBy:          insertion.DeletedBy,
+		DeleteReason:       insertion.DeleteReason,
+	}
 }
 
 func (s *TransactionService) CreateInsertionTransaction(dto *model.CreateInsertionTransactionDTO) (*model.CreateInsertionTransactionResponse, error) {
@@ -129,348 +296,682 @@ func (s *TransactionService) CreateInsertionTransaction(dto *model.CreateInserti
 		return nil, fmt.Errorf("dto cannot be nil")
 	}
 
+	counting, err := s.opnameRepository.IsCountingCategory(dto.ItemRequest.CategoryID)
+	if err != nil {
+		return nil, err
+	}
+	if counting {
+		return nil, utils.ErrStockOpnameInProgress
+	}
+
+	employeeID, err := s.resolveEmployee(dto.EmployeeID, &dto.EmployeeName, &dto.EmployeeDepartment, &dto.EmployeePosition)
+	if err != nil {
+		return nil, err
+	}
+
 	transaction := &model.InsertionTransaction{
 		UUID:               uuid.New(),
 		TransactionType:    "insert",
 		EmployeeName:       dto.EmployeeName,
 		EmployeeDepartment: dto.EmployeeDepartment,
 		EmployeePosition:   dto.EmployeePosition,
+		EmployeeID:         employeeID,
 		Notes:              dto.Notes,
 		Time:               time.Now(),
-		Status:             "pending",
+		Status:             StatusPending,
 		Image:              dto.Image,
 		ItemRequest:        dto.ItemRequest,
 		ItemID:             nil,
 		Item:               nil,
 	}
 
-	createdTransaction, err := s.logRepository.CreateInsertionTransaction(transaction)
+	token, tokenHash, err := newTrackingToken()
+	if err != nil {
+		return nil, err
+	}
+	transaction.TrackingTokenHash = tokenHash
+
+	var createdTransaction *model.InsertionTransaction
+	err = s.logRepository.Transaction(func(tx *gorm.DB) error {
+		logRepository := s.logRepository.WithTx(tx)
+
+		var err error
+		createdTransaction, err = logRepository.CreateInsertionTransaction(transaction)
+		if err != nil {
+			return err
+		}
+
+		return recordStatusChange(logRepository, "insert", createdTransaction.UUID, nil, "", StatusPending, nil, "", "")
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to create insertion transaction: %w", err)
 	}
 
 	response := &model.CreateInsertionTransactionResponse{
-		Message:      "Insertion transaction created successfully",
-		ID:           createdTransaction.UUID.String(),
-		EmployeeName: createdTransaction.EmployeeName,
-		ItemName:     createdTransaction.ItemRequest.Name,
-		Quantity:     createdTransaction.ItemRequest.Quantity,
+		Message:       "Insertion transaction created successfully",
+		ID:            createdTransaction.UUID.String(),
+		TrackingToken: token,
+		EmployeeName:  createdTransaction.EmployeeName,
+		ItemName:      createdTransaction.ItemRequest.Name,
+		Quantity:      createdTransaction.ItemRequest.Quantity,
 	}
 
 	return response, nil
 }
 
 func (s *TransactionService) CreateLoanTransaction(loan model.LoanTransaction) (*model.CreateLoanTransactionResponse, error) {
-	item, err := s.itemRepository.GetItemByID(fmt.Sprintf("%d", loan.ItemID))
+	lines, items, quantity, err := s.prepareLines(loan.ItemID, loan.Quantity, loan.Lines)
 	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, fmt.Errorf("item with ID %d not found", loan.ItemID)
-		}
-		return nil, fmt.Errorf("error fetching item: %w", err)
+		return nil, err
+	}
+
+	loan.EmployeeID, err = s.resolveEmployee(loan.EmployeeID, &loan.EmployeeName, &loan.EmployeeDepartment, &loan.EmployeePosition)
+	if err != nil {
+		return nil, err
 	}
+	loan.Employee = nil
 
 	loan.UUID = uuid.New()
 
 	loan.TransactionType = "loan"
 	loan.LoanTime = time.Now()
 	loan.Time = time.Now()
-	loan.Status = "pending"
+	loan.Status = StatusPending
+	loan.Quantity = quantity
+	loan.ReturnedQuantity = 0
+	loan.Lines = lines
+	loan.Returns = nil
+	loan.Extensions = nil
+	loan.StatusReason = ""
+	loan.AdminNote = ""
+
+	token, tokenHash, err := newTrackingToken()
+	if err != nil {
+		return nil, err
+	}
+	loan.TrackingTokenHash = tokenHash
+
+	var item *model.Item
+	loan.ItemID = nil
+	if len(lines) == 1 {
+		loan.ItemID = &lines[0].ItemID
+		item = items[0]
+	}
+
+	var createdTransaction *model.LoanTransaction
+	err = s.logRepository.Transaction(func(tx *gorm.DB) error {
+		logRepository := s.logRepository.WithTx(tx)
+
+		createdTransaction, err = logRepository.CreateLoanTransaction(loan)
+		if err != nil {
+			return err
+		}
+
+		if err := recordStatusChange(logRepository, "loan", createdTransaction.UUID, nil, "", StatusPending, nil, "", ""); err != nil {
+			return err
+		}
+
+		// Requests under an approval chain wait for every step instead.
+		chained, err := startApprovalChain(s.chainRepository.WithTx(tx), logRepository, "loan", createdTransaction.ID, createdTransaction.Lines, items)
+		if err != nil || chained {
+			return err
+		}
+
+		itemRepository := s.itemRepository.WithTx(tx).WithSource(fmt.Sprintf("%s_%s", "loan", createdTransaction.UUID), nil)
+		rule, err := autoApprove(s.ruleRepository.WithTx(tx), logRepository, itemRepository, "loan", createdTransaction.UUID, createdTransaction.EmployeeDepartment, createdTransaction.Lines)
+		if err != nil || rule == nil {
+			return err
+		}
 
-	createdTransaction, err := s.logRepository.CreateLoanTransaction(loan)
+		createdTransaction.Status = StatusApproved
+		createdTransaction.AutoApprovalRuleID = &rule.ID
+		return logRepository.UpdateLoanTransaction(createdTransaction)
+	})
 	if err != nil {
-		item.Quantity += loan.Quantity
-		_ = s.itemRepository.UpdateItem(*item)
 		return nil, fmt.Errorf("failed to create loan transaction log: %w", err)
 	}
 
 	response := &model.CreateLoanTransactionResponse{
-		Message:      "Loan transaction created successfully",
-		ID:           createdTransaction.UUID.String(),
-		EmployeeName: createdTransaction.EmployeeName,
-		Item:         item,
-		Quantity:     createdTransaction.Quantity,
-		LoanTime:     createdTransaction.LoanTime,
-		ReturnTime:   createdTransaction.ReturnTime,
+		Message:            "Loan transaction created successfully",
+		ID:                 createdTransaction.UUID.String(),
+		TrackingToken:      token,
+		Status:             createdTransaction.Status,
+		AutoApprovalRuleID: createdTransaction.AutoApprovalRuleID,
+		EmployeeName:       createdTransaction.EmployeeName,
+		Item:               item,
+		Quantity:           createdTransaction.Quantity,
+		Lines:              attachLineItems(createdTransaction.Lines, items),
+		LoanTime:           createdTransaction.LoanTime,
+		ReturnTime:         createdTransaction.ReturnTime,
 	}
 
 	return response, nil
 }
 
 func (s *TransactionService) CreateInquiryTransaction(inquiry model.InquiryTransaction) (*model.CreateInquiryTransactionResponse, error) {
-	item, err := s.itemRepository.GetItemByID(fmt.Sprintf("%d", inquiry.ItemID))
+	lines, items, quantity, err := s.prepareLines(inquiry.ItemID, inquiry.Quantity, inquiry.Lines)
 	if err != nil {
-		return nil, fmt.Errorf("item not found: %w", err)
+		return nil, err
 	}
 
+	inquiry.EmployeeID, err = s.resolveEmployee(inquiry.EmployeeID, &inquiry.EmployeeName, &inquiry.EmployeeDepartment, &inquiry.EmployeePosition)
+	if err != nil {
+		return nil, err
+	}
+	inquiry.Employee = nil
+
 	inquiry.UUID = uuid.New()
 	inquiry.TransactionType = "inquiry"
 	inquiry.Time = time.Now()
-	inquiry.Status = "pending"
+	inquiry.Status = StatusPending
+	inquiry.Quantity = quantity
+	inquiry.Lines = lines
+	inquiry.StatusReason = ""
+	inquiry.AdminNote = ""
 
-	createdTransaction, err := s.logRepository.CreateInquiryTransaction(inquiry)
+	token, tokenHash, err := newTrackingToken()
+	if err != nil {
+		return nil, err
+	}
+	inquiry.TrackingTokenHash = tokenHash
+
+	var item *model.Item
+	inquiry.ItemID = nil
+	if len(lines) == 1 {
+		inquiry.ItemID = &lines[0].ItemID
+		item = items[0]
+	}
+
+	var createdTransaction *model.InquiryTransaction
+	var warnings []model.QuotaWarning
+	err = s.logRepository.Transaction(func(tx *gorm.DB) error {
+		logRepository := s.logRepository.WithTx(tx)
+		quotaRepository := s.quotaRepository.WithTx(tx)
+
+		warnings, err = checkQuotas(quotaRepository, inquiry.EmployeeID, inquiry.EmployeeName, inquiry.EmployeeDepartment, lines, items)
+		if err != nil {
+			return err
+		}
+		inquiry.QuotaExceeded = len(warnings) > 0
+
+		createdTransaction, err = logRepository.CreateInquiryTransaction(inquiry)
+		if err != nil {
+			return err
+		}
+
+		violations := make([]model.QuotaViolation, 0, len(warnings))
+		for _, warning := range warnings {
+			violations = append(violations, model.QuotaViolation{
+				QuotaID:              warning.QuotaID,
+				InquiryTransactionID: createdTransaction.ID,
+				Used:                 warning.Used,
+				Requested:            warning.Requested,
+				Limit:                warning.Limit,
+				Time:                 createdTransaction.Time,
+			})
+		}
+		if err := quotaRepository.CreateQuotaViolations(violations); err != nil {
+			return err
+		}
+
+		if err := recordStatusChange(logRepository, "inquiry", createdTransaction.UUID, nil, "", StatusPending, nil, "", ""); err != nil {
+			return err
+		}
+
+		// Requests under an approval chain wait for every step, and requests
+		// over a quota are always left for an admin to decide.
+		chained, err := startApprovalChain(s.chainRepository.WithTx(tx), logRepository, "inquiry", createdTransaction.ID, createdTransaction.Lines, items)
+		if err != nil || chained || createdTransaction.QuotaExceeded {
+			return err
+		}
+
+		itemRepository := s.itemRepository.WithTx(tx).WithSource(fmt.Sprintf("%s_%s", "inquiry", createdTransaction.UUID), nil)
+		rule, err := autoApprove(s.ruleRepository.WithTx(tx), logRepository, itemRepository, "inquiry", createdTransaction.UUID, createdTransaction.EmployeeDepartment, createdTransaction.Lines)
+		if err != nil || rule == nil {
+			return err
+		}
+
+		createdTransaction.Status = StatusApproved
+		createdTransaction.AutoApprovalRuleID = &rule.ID
+		return logRepository.UpdateInquiryTransaction(createdTransaction)
+	})
 	if err != nil {
-		item.Quantity += inquiry.Quantity
-		_ = s.itemRepository.UpdateItem(*item)
 		return nil, fmt.Errorf("failed to create inquiry transaction log: %w", err)
 	}
 
 	response := &model.CreateInquiryTransactionResponse{
-		Message:      "Inquiry transaction created successfully",
-		ID:           createdTransaction.UUID.String(),
-		EmployeeName: createdTransaction.EmployeeName,
-		Item:         item,
-		Quantity:     createdTransaction.Quantity,
+		Message:            "Inquiry transaction created successfully",
+		ID:                 createdTransaction.UUID.String(),
+		TrackingToken:      token,
+		Status:             createdTransaction.Status,
+		AutoApprovalRuleID: createdTransaction.AutoApprovalRuleID,
+		EmployeeName:       createdTransaction.EmployeeName,
+		Item:               item,
+		Quantity:           createdTransaction.Quantity,
+		Lines:              attachLineItems(createdTransaction.Lines, items),
+		QuotaWarnings:      warnings,
 	}
 
 	return response, nil
 }
 
-func (s *TransactionService) UpdateTransactionStatus(status, uuidStr string) (*model.UpdateTransactionResponse, error) {
+// parseTransactionID splits a prefixed transaction ID such as loan_<uuid>
+// into its transaction type and UUID.
+func parseTransactionID(uuidStr string) (string, uuid.UUID, error) {
 	parts := strings.Split(uuidStr, "_")
 	if len(parts) != 2 {
-		return nil, fmt.Errorf("invalid UUID format, expected type_UUID but got: %s", uuidStr)
+		return "", uuid.Nil, fmt.Errorf("invalid UUID format, expected type_UUID but got: %s", uuidStr)
 	}
 
-	transactionType := parts[0]
-	uuid, err := uuid.Parse(parts[1])
+	id, err := uuid.Parse(parts[1])
 	if err != nil {
-		return nil, fmt.Errorf("invalid UUID: %w", err)
+		return "", uuid.Nil, fmt.Errorf("invalid UUID: %w", err)
+	}
+
+	return parts[0], id, nil
+}
+
+// UpdateTransactionStatus moves a transaction to a new status on behalf of
+// the given admin and records the change in its timeline.
+func (s *TransactionService) UpdateTransactionStatus(status, uuidStr string, adminID *uint, req model.UpdateTransactionStatusRequest) (*model.UpdateTransactionResponse, error) {
+	transactionType, uuid, err := parseTransactionID(uuidStr)
+	if err != nil {
+		return nil, err
 	}
 
 	status = strings.ToLower(status)
 
+	req.Reason = strings.TrimSpace(req.Reason)
+	if requiresReason(status) && req.Reason == "" {
+		return nil, utils.ErrReasonRequired
+	}
+
 	switch transactionType {
 	case "loan":
-		return s.updateLoanTransaction(uuid, status)
+		return s.updateLoanTransaction(uuid, status, adminID, req)
 	case "inquiry":
-		return s.updateInquiryTransaction(uuid, status)
+		return s.updateInquiryTransaction(uuid, status, adminID, req)
 	case "insert":
-		return s.updateInsertionTransaction(uuid, status)
+		return s.updateInsertionTransaction(uuid, status, adminID, req)
+	case "transfer":
+		return s.updateTransferTransaction(uuid, status, adminID, req)
 	default:
 		return nil, utils.ErrTransactionType
 	}
 }
 
-func (s *TransactionService) updateLoanTransaction(uuid uuid.UUID, status string) (*model.UpdateTransactionResponse, error) {
-	loan, err := s.logRepository.GetLoanTransactionByUUID(uuid)
+// GetTransactionTransitions lists the statuses a transaction can currently be
+// moved to.
+func (s *TransactionService) GetTransactionTransitions(uuidStr string) (*model.TransactionTransitionsResponse, error) {
+	transactionType, uuid, err := parseTransactionID(uuidStr)
 	if err != nil {
-		return nil, utils.ErrTransactionNotFound
-	}
-
-	if loan.Status == "returned" {
-		return nil, fmt.Errorf("loan transaction already returned")
+		return nil, err
 	}
 
-	item := loan.Item
-
-	switch status {
-	case "returned":
-		item.Quantity += loan.Quantity
-		now := time.Now()
-		loan.ReturnedTime = &now
-		if err := s.itemRepository.UpdateItem(*item); err != nil {
-			return nil, fmt.Errorf("failed to update item quantity: %w", err)
+	var id uint
+	var status string
+	var lines []model.TransactionLine
+	switch transactionType {
+	case "loan":
+		loan, err := s.logRepository.GetLoanTransactionByUUID(uuid)
+		if err != nil {
+			return nil, utils.ErrTransactionNotFound
 		}
-	case "completed":
-		if item.Quantity < loan.Quantity {
-			return nil, fmt.Errorf("insufficient quantity")
+		id = loan.ID
+		status = loan.Status
+		lines = loan.Lines
+	case "inquiry":
+		inquiry, err := s.logRepository.GetInquiryTransactionByUUID(uuid)
+		if err != nil {
+			return nil, utils.ErrTransactionNotFound
 		}
-
-		item.Quantity -= loan.Quantity
-		now := time.Now()
-		loan.CompletedTime = &now
-		if err := s.itemRepository.UpdateItem(*item); err != nil {
-			return nil, fmt.Errorf("failed to update item quantity: %w", err)
+		id = inquiry.ID
+		status = inquiry.Status
+		lines = inquiry.Lines
+	case "insert":
+		insertion, err := s.logRepository.GetInsertionTransactionByUUID(uuid)
+		if err != nil {
+			return nil, utils.ErrTransactionNotFound
 		}
-	case "approved":
-	case "incomplete":
-	case "rejected":
+		status = insertion.Status
+	case "transfer":
+		transfer, err := s.logRepository.GetTransferTransactionByUUID(uuid)
+		if err != nil {
+			return nil, utils.ErrTransactionNotFound
+		}
+		status = transfer.Status
 	default:
-		return nil, fmt.Errorf("invalid status")
-	}
-
-	loan.Status = status
-	if err := s.logRepository.UpdateLoanTransaction(loan); err != nil {
-		return nil, fmt.Errorf("failed to update loan transaction: %w", err)
+		return nil, utils.ErrTransactionType
 	}
 
-	return &model.UpdateTransactionResponse{
-		Message: fmt.Sprintf("Loan transaction %s successfully", status),
-		ID:      uuid.String(),
-	}, nil
-}
-
-func (s *TransactionService) updateInquiryTransaction(uuid uuid.UUID, status string) (*model.UpdateTransactionResponse, error) {
-	inquiry, err := s.logRepository.GetInquiryTransactionByUUID(uuid)
-	if err != nil {
-		return nil, utils.ErrTransactionNotFound
+	// Approving waits for the approval chain, if the request has one.
+	awaitingChain := false
+	if len(lines) > 0 {
+		awaitingChain, err = s.logRepository.HasPendingApprovals(transactionType, id)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	item := inquiry.Item
-
-	switch status {
-	case "completed":
-		if item.Quantity < inquiry.Quantity {
-			return nil, fmt.Errorf("insufficient quantity")
+	lineTransitions := make([]model.LineTransitionsResponse, 0, len(lines))
+	for _, line := range lines {
+		next := []string{}
+		if isOpenStatus(status) {
+			next = allowedLineTransitions(line.Status)
 		}
-
-		item.Quantity -= inquiry.Quantity
-		now := time.Now()
-		inquiry.CompletedTime = &now
-		if err := s.itemRepository.UpdateItem(*item); err != nil {
-			return nil, fmt.Errorf("failed to update item quantity: %w", err)
+		if awaitingChain {
+			next = withoutStatus(next, StatusApproved)
 		}
-	case "approved":
-	case "incomplete":
-	case "rejected":
-	default:
-		return nil, fmt.Errorf("invalid status")
+		lineTransitions = append(lineTransitions, model.LineTransitionsResponse{
+			ID:          line.ID,
+			Status:      line.Status,
+			Transitions: next,
+		})
 	}
 
-	inquiry.Status = status
-	if err := s.logRepository.UpdateInquiryTransaction(inquiry); err != nil {
-		return nil, fmt.Errorf("failed to update inquiry transaction: %w", err)
+	transitions := allowedTransitions(transactionType, status)
+	if awaitingChain {
+		transitions = withoutStatus(transitions, StatusApproved)
 	}
 
-	return &model.UpdateTransactionResponse{
-		Message: fmt.Sprintf("Inquiry transaction %s successfully", status),
-		ID:      uuid.String(),
+	return &model.TransactionTransitionsResponse{
+		ID:          uuidStr,
+		Status:      status,
+		Transitions: transitions,
+		Lines:       lineTransitions,
 	}, nil
 }
 
-func (s *TransactionService) updateInsertionTransaction(uuid uuid.UUID, status string) (*model.UpdateTransactionResponse, error) {
-	insertion, err := s.logRepository.GetInsertionTransactionByUUID(uuid)
-	if err != nil {
-		return nil, utils.ErrTransactionNotFound
-	}
+func (s *TransactionService) updateLoanTransaction(uuid uuid.UUID, status string, adminID *uint, req model.UpdateTransactionStatusRequest) (*model.UpdateTransactionResponse, error) {
+	err := s.logRepository.Transaction(func(tx *gorm.DB) error {
+		logRepository := s.logRepository.WithTx(tx)
+		itemRepository := s.itemRepository.WithTx(tx).WithSource(fmt.Sprintf("%s_%s", "loan", uuid), adminID)
+		opnameRepository := s.opnameRepository.WithTx(tx)
 
-	switch status {
-	case "completed":
-		existingItem, err := s.itemRepository.GetItemByName(insertion.ItemRequest.Name)
-		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, fmt.Errorf("failed to check existing item: %w", err)
+		loan, err := logRepository.GetLoanTransactionByUUIDForUpdate(uuid)
+		if err != nil {
+			return utils.ErrTransactionNotFound
 		}
 
-		var item *model.Item
-		if existingItem != nil {
-			existingItem.Quantity += insertion.ItemRequest.Quantity
-			existingItem.Shelf = insertion.ItemRequest.Shelf
-			existingItem.CategoryID = insertion.ItemRequest.CategoryID
+		if err := checkTransition("loan", loan.Status, status); err != nil {
+			return err
+		}
+		if status == StatusApproved {
+			if err := checkApprovalChain(logRepository, "loan", loan.ID); err != nil {
+				return err
+			}
+		}
 
-			if err := s.itemRepository.UpdateItem(*existingItem); err != nil {
-				return nil, fmt.Errorf("failed to update existing item: %w", err)
+		lines, err := logRepository.GetTransactionLinesForUpdate("loan", loan.ID)
+		if err != nil {
+			return err
+		}
+		if err := checkLinesNotHandedOut("loan", lines, status); err != nil {
+			return err
+		}
+		switch status {
+		case StatusCompleted:
+			if err := checkNotCounting(opnameRepository, lineItemIDs(lines, StatusApproved)); err != nil {
+				return err
 			}
-			item = existingItem
-		} else {
-			newItem := &model.Item{
-				Name:       insertion.ItemRequest.Name,
-				Quantity:   insertion.ItemRequest.Quantity,
-				Shelf:      insertion.ItemRequest.Shelf,
-				CategoryID: insertion.ItemRequest.CategoryID,
+		case StatusReturned:
+			if err := checkNotCounting(opnameRepository, lineItemIDs(lines, StatusCompleted)); err != nil {
+				return err
 			}
+		}
 
-			createdItem, err := s.itemRepository.CreateItem(newItem)
+		if status == StatusReturned {
+			conditions, err := returnConditions(req.Conditions, lines)
 			if err != nil {
-				return nil, fmt.Errorf("failed to create new item: %w", err)
+				return err
+			}
+
+			for i := range lines {
+				line := &lines[i]
+				if line.Status != StatusCompleted {
+					continue
+				}
+				condition := conditions[line.ID]
+				if err := returnLineQuantity(logRepository, itemRepository, loan, line, line.Quantity-line.ReturnedQuantity, condition.Damaged, condition.Lost, adminID); err != nil {
+					return err
+				}
 			}
-			item = createdItem
+
+			return finishLoanReturn(logRepository, loan, lines, adminID, req)
 		}
 
-		itemID := item.ID
-		insertion.ItemID = &itemID
-		insertion.Item = item
-		now := time.Now()
-		insertion.CompletedTime = &now
-		if err := s.logRepository.UpdateInsertionTransaction(insertion); err != nil {
-			return nil, fmt.Errorf("failed to update insertion transaction: %w", err)
+		if err := applyStatusToLines(logRepository, itemRepository, lines, status); err != nil {
+			return err
+		}
+
+		if status == StatusCompleted {
+			now := time.Now()
+			loan.CompletedTime = &now
+		}
+
+		if err := recordStatusChange(logRepository, "loan", loan.UUID, nil, loan.Status, status, adminID, req.Reason, req.Comment); err != nil {
+			return err
+		}
+
+		loan.Status = status
+		loan.StatusReason = req.Reason
+		if req.AdminNote != "" {
+			loan.AdminNote = req.AdminNote
+		}
+		if err := logRepository.UpdateLoanTransaction(loan); err != nil {
+			return fmt.Errorf("failed to update loan transaction: %w", err)
 		}
-	case "approved":
-	case "incomplete":
-	case "rejected":
-	default:
-		return nil, fmt.Errorf("invalid status")
-	}
 
-	insertion.Status = status
-	if err := s.logRepository.UpdateInsertionTransaction(insertion); err != nil {
-		return nil, fmt.Errorf("failed to update insertion transaction: %w", err)
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return &model.UpdateTransactionResponse{
-		Message: fmt.Sprintf("Insertion transaction %s successfully", status),
+		Message: fmt.Sprintf("Loan transaction %s successfully", status),
 		ID:      uuid.String(),
 	}, nil
 }
 
-func (s *TransactionService) DeleteTransaction(uuidStr string) (*model.DeleteTransactionResponse, error) {
-	parts := strings.Split(uuidStr, "_")
-	if len(parts) != 2 {
-		return nil, fmt.Errorf("invalid UUID format, expected type_UUID but got: %s", uuidStr)
-	}
-
-	transactionType := parts[0]
-	uuid, err := uuid.Parse(parts[1])
+// ReturnLoanTransaction puts quantity units of a loan line back into stock.
+// The line may be omitted when only one line is still out. The loan stays
+// open until every handed-out unit has been returned.
+func (s *TransactionService) ReturnLoanTransaction(uuidStr string, req model.ReturnLoanRequest, adminID *uint) (*model.ReturnLoanResponse, error) {
+	transactionType, uuid, err := parseTransactionID(uuidStr)
 	if err != nil {
-		return nil, fmt.Errorf("invalid UUID: %w", err)
+		return nil, err
 	}
-
-	switch transactionType {
-	case "loan":
-		return s.deleteLoanTransaction(uuid)
-	case "inquiry":
-		return s.deleteInquiryTransaction(uuid)
-	case "insert":
-		return s.deleteInsertionTransaction(uuid)
-	default:
+	if transactionType != "loan" {
 		return nil, utils.ErrTransactionType
 	}
-}
 
-func (s *TransactionService) deleteLoanTransaction(uuid uuid.UUID) (*model.DeleteTransactionResponse, error) {
-	if _, err := s.logRepository.GetLoanTransactionByUUID(uuid); err != nil {
-		return nil, utils.ErrTransactionNotFound
+	var loan *model.LoanTransaction
+	var lines []model.TransactionLine
+	err = s.logRepository.Transaction(func(tx *gorm.DB) error {
+		logRepository := s.logRepository.WithTx(tx)
+		itemRepository := s.itemRepository.WithTx(tx).WithSource(uuidStr, adminID)
+
+		loan, err = logRepository.GetLoanTransactionByUUIDForUpdate(uuid)
+		if err != nil {
+			return utils.ErrTransactionNotFound
+		}
+
+		if err := checkTransition("loan", loan.Status, StatusReturned); err != nil {
+			return err
+		}
+
+		lines, err = logRepository.GetTransactionLinesForUpdate("loan", loan.ID)
+		if err != nil {
+			return err
+		}
+
+		var line *model.TransactionLine
+		for i := range lines {
+			switch {
+			case req.LineID != 0 && lines[i].ID == req.LineID:
+				line = &lines[i]
+			case req.LineID == 0 && lines[i].Status == StatusCompleted:
+				if line != nil {
+					return utils.ErrLineNotFound
+				}
+				line = &lines[i]
+			}
+		}
+		if line == nil {
+			return utils.ErrLineNotFound
+		}
+		if err := checkNotCounting(s.opnameRepository.WithTx(tx), []uint{line.ItemID}); err != nil {
+			return err
+		}
+
+		if err := returnLineQuantity(logRepository, itemRepository, loan, line, req.Quantity, req.Damaged, req.Lost, adminID); err != nil {
+			return err
+		}
+
+		return finishLoanReturn(logRepository, loan, lines, adminID, model.UpdateTransactionStatusRequest{})
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	if err := s.logRepository.DeleteLoanTransactionByUUID(uuid); err != nil {
-		return nil, fmt.Errorf("failed to delete loan transaction: %w", err)
+	returns, err := s.logRepository.GetLoanReturns(loan.ID)
+	if err != nil {
+		return nil, err
 	}
 
-	return &model.DeleteTransactionResponse{
-		Message: "Loan transaction deleted successfully",
-		ID:      uuid.String(),
+	return &model.ReturnLoanResponse{
+		Message:             fmt.Sprintf("Returned %d item(s) successfully", req.Quantity),
+		ID:                  uuid.String(),
+		Status:              loan.Status,
+		ReturnedQuantity:    loan.ReturnedQuantity,
+		OutstandingQuantity: outstandingQuantity(lines),
+		Returns:             returns,
 	}, nil
 }
 
-func (s *TransactionService) deleteInquiryTransaction(uuid uuid.UUID) (*model.DeleteTransactionResponse, error) {
-	if _, err := s.logRepository.GetInquiryTransactionByUUID(uuid); err != nil {
-		return nil, utils.ErrTransactionNotFound
-	}
+func (s *TransactionService) updateInquiryTransaction(uuid uuid.UUID, status string, adminID *uint, req model.UpdateTransactionStatusRequest) (*model.UpdateTransactionResponse, error) {
+	err := s.logRepository.Transaction(func(tx *gorm.DB) error {
+		logRepository := s.logRepository.WithTx(tx)
+		itemRepository := s.itemRepository.WithTx(tx).WithSource(fmt.Sprintf("%s_%s", "inquiry", uuid), adminID)
+		opnameRepository := s.opnameRepository.WithTx(tx)
+
+		inquiry, err := logRepository.GetInquiryTransactionByUUIDForUpdate(uuid)
+		if err != nil {
+			return utils.ErrTransactionNotFound
+		}
+
+		if err := checkTransition("inquiry", inquiry.Status, status); err != nil {
+			return err
+		}
+		if status == StatusApproved {
+			if err := checkApprovalChain(logRepository, "inquiry", inquiry.ID); err != nil {
+				return err
+			}
+		}
+
+		lines, err := logRepository.GetTransactionLinesForUpdate("inquiry", inquiry.ID)
+		if err != nil {
+			return err
+		}
+		if err := checkLinesNotHandedOut("inquiry", lines, status); err != nil {
+			return err
+		}
+		if status == StatusCompleted {
+			if err := checkNotCounting(opnameRepository, lineItemIDs(lines, StatusApproved)); err != nil {
+				return err
+			}
+		}
+
+		if err := applyStatusToLines(logRepository, itemRepository, lines, status); err != nil {
+			return err
+		}
 
-	if err := s.logRepository.DeleteInquiryTransactionByUUID(uuid); err != nil {
-		return nil, fmt.Errorf("failed to delete inquiry transaction: %w", err)
+		if status == StatusCompleted {
+			now := time.Now()
+			inquiry.CompletedTime = &now
+		}
+
+		if err := recordStatusChange(logRepository, "inquiry", inquiry.UUID, nil, inquiry.Status, status, adminID, req.Reason, req.Comment); err != nil {
+			return err
+		}
+
+		inquiry.Status = status
+		inquiry.StatusReason = req.Reason
+		if req.AdminNote != "" {
+			inquiry.AdminNote = req.AdminNote
+		}
+		if err := logRepository.UpdateInquiryTransaction(inquiry); err != nil {
+			return fmt.Errorf("failed to update inquiry transaction: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	return &model.DeleteTransactionResponse{
-		Message: "Inquiry transaction deleted successfully",
+	return &model.UpdateTransactionResponse{
+		Message: fmt.Sprintf("Inquiry transaction %s successfully", status),
 		ID:      uuid.String(),
 	}, nil
 }
 
-func (s *TransactionService) deleteInsertionTransaction(uuid uuid.UUID) (*model.DeleteTransactionResponse, error) {
-	if _, err := s.logRepository.GetInsertionTransactionByUUID(uuid); err != nil {
-		return nil, utils.ErrTransactionNotFound
-	}
+func (s *TransactionService) updateInsertionTransaction(uuid uuid.UUID, status string, adminID *uint, req model.UpdateTransactionStatusRequest) (*model.UpdateTransactionResponse, error) {
+	err := s.logRepository.Transaction(func(tx *gorm.DB) error {
+		logRepository := s.logRepository.WithTx(tx)
+		itemRepository := s.itemRepository.WithTx(tx).WithSource(fmt.Sprintf("%s_%s", "insert", uuid), adminID)
+
+		insertion, err := logRepository.GetInsertionTransactionByUUIDForUpdate(uuid)
+		if err != nil {
+			return utils.ErrTransactionNotFound
+		}
+
+		if err := checkTransition("insert", insertion.Status, status); err != nil {
+			return err
+		}
 
-	if err := s.logRepository.DeleteInsertionTransactionByUUID(uuid); err != nil {
-		return nil, fmt.Errorf("failed to delete insertion transaction: %w", err)
+		switch status {
+		case StatusCompleted:
+			itemID, err := stockInsertion(itemRepository, insertion.ItemRequest, req)
+			if err != nil {
+				return err
+			}
+			if err := checkNotCounting(s.opnameRepository.WithTx(tx), []uint{itemID}); err != nil {
+				return err
+			}
+
+			insertion.ItemID = &itemID
+			now := time.Now()
+			insertion.CompletedTime = &now
+		case StatusApproved, StatusIncomplete, StatusRejected:
+		}
+
+		if err := recordStatusChange(logRepository, "insert", insertion.UUID, nil, insertion.Status, status, adminID, req.Reason, req.Comment); err != nil {
+			return err
+		}
+
+		insertion.Status = status
+		insertion.StatusReason = req.Reason
+		if req.AdminNote != "" {
+			insertion.AdminNote = req.AdminNote
+		}
+		if err := logRepository.UpdateInsertionTransaction(insertion); err != nil {
+			return fmt.Errorf("failed to update insertion transaction: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	return &model.DeleteTransactionResponse{
-		Message: "Insertion transaction deleted successfully",
+	return &model.UpdateTransactionResponse{
+		Message: fmt.Sprintf("Insertion transaction %s successfully", status),
 		ID:      uuid.String(),
 	}, nil
 }
 
-func (s *TransactionService) ExportTransactions(from, to time.Time) ([]model.ExportTransaction, error) {
-	return s.logRepository.ExportTransactions(from, to)
+func (s *TransactionService) ExportTransactions(from, to time.Time, includeDeleted bool) ([]model.ExportTransaction, error) {
+	return s.logRepository.ExportTransactions(from, to, includeDeleted)
 }
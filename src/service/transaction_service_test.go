@@ -0,0 +1,53 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"gtihub.com/raditsoic/telkom-storage-ms/src/model"
+	"gtihub.com/raditsoic/telkom-storage-ms/src/utils"
+)
+
+func TestTransactionCursor(t *testing.T) {
+	entry := model.TransactionFeedEntry{TransactionType: "loan", ID: 4, Status: StatusApproved, Time: time.Date(2024, time.May, 1, 8, 0, 0, 0, time.UTC)}
+
+	tests := []struct {
+		name    string
+		issued  model.TransactionFilter
+		reused  model.TransactionFilter
+		want    error
+		wantVal string
+	}{
+		{"default order", model.TransactionFilter{}, model.TransactionFilter{Sort: "time", Order: "desc"}, nil, ""},
+		{"same sort", model.TransactionFilter{Sort: "status", Order: "asc"}, model.TransactionFilter{Sort: "status", Order: "asc"}, nil, StatusApproved},
+		{"other sort", model.TransactionFilter{Sort: "status"}, model.TransactionFilter{Sort: "employee"}, utils.ErrCursorOrderMismatch, ""},
+		{"other order", model.TransactionFilter{}, model.TransactionFilter{Order: "asc"}, utils.ErrCursorOrderMismatch, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cursor, err := decodeTransactionCursor(encodeTransactionCursor(tt.issued, entry), tt.reused)
+			if tt.want != nil {
+				if !errors.Is(err, tt.want) {
+					t.Fatalf("decodeTransactionCursor() = %v, want %v", err, tt.want)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("decodeTransactionCursor() = %v", err)
+			}
+			if cursor.Type != entry.TransactionType || cursor.ID != entry.ID || !cursor.Time.Equal(entry.Time) || cursor.Value != tt.wantVal {
+				t.Fatalf("decodeTransactionCursor() = %+v", cursor)
+			}
+		})
+	}
+}
+
+func TestDecodeInvalidTransactionCursor(t *testing.T) {
+	for _, cursor := range []string{"not base64!", "bm90IGpzb24", "e30"} {
+		if _, err := decodeTransactionCursor(cursor, model.TransactionFilter{}); !errors.Is(err, utils.ErrInvalidCursor) {
+			t.Fatalf("decodeTransactionCursor(%q) = %v, want %v", cursor, err, utils.ErrInvalidCursor)
+		}
+	}
+}
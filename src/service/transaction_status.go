@@ -0,0 +1,102 @@
+package service
+
+import (
+	"gtihub.com/raditsoic/telkom-storage-ms/src/utils"
+)
+
+// Transaction statuses
+const (
+	StatusPending    = "pending"
+	StatusApproved   = "approved"
+	StatusIncomplete = "incomplete"
+	StatusRejected   = "rejected"
+	StatusCompleted  = "completed"
+	StatusReturned   = "returned"
+	StatusOverdue    = "overdue"
+	StatusCancelled  = "cancelled"
+)
+
+// transactionTransitions lists, per transaction type, the statuses each
+// status is allowed to move to. Statuses without an entry are final. Loans are
+// only ever moved to overdue by the overdue scheduler, and requests are only
+// cancelled by their requester, never through this table.
+var transactionTransitions = map[string]map[string][]string{
+	"loan": {
+		StatusPending:    {StatusApproved, StatusIncomplete, StatusRejected},
+		StatusIncomplete: {StatusApproved, StatusRejected},
+		StatusApproved:   {StatusCompleted, StatusIncomplete, StatusRejected},
+		StatusCompleted:  {StatusReturned},
+		StatusOverdue:    {StatusReturned},
+	},
+	"inquiry": {
+		StatusPending:    {StatusApproved, StatusIncomplete, StatusRejected},
+		StatusIncomplete: {StatusApproved, StatusRejected},
+		StatusApproved:   {StatusCompleted, StatusIncomplete, StatusRejected},
+	},
+	"insert": {
+		StatusPending:    {StatusApproved, StatusIncomplete, StatusRejected},
+		StatusIncomplete: {StatusApproved, StatusRejected},
+		StatusApproved:   {StatusCompleted, StatusIncomplete, StatusRejected},
+	},
+	"transfer": {
+		StatusPending:    {StatusApproved, StatusIncomplete, StatusRejected},
+		StatusIncomplete: {StatusApproved, StatusRejected},
+		StatusApproved:   {StatusCompleted, StatusIncomplete, StatusRejected},
+	},
+}
+
+// allowedTransitions returns the statuses a transaction of the given type can
+// move to from its current status.
+func allowedTransitions(transactionType, from string) []string {
+	next := transactionTransitions[transactionType][from]
+	if next == nil {
+		return []string{}
+	}
+
+	return next
+}
+
+// isKnownStatus reports whether status appears anywhere in the transition
+// table of the given transaction type.
+func isKnownStatus(transactionType, status string) bool {
+	for from, next := range transactionTransitions[transactionType] {
+		if from == status {
+			return true
+		}
+		for _, to := range next {
+			if to == status {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// isCancellable reports whether a requester can still cancel a request in the
+// given status.
+func isCancellable(status string) bool {
+	return status == StatusPending || status == StatusApproved
+}
+
+// requiresReason reports whether moving a transaction to status needs a
+// reason for the requester.
+func requiresReason(status string) bool {
+	return status == StatusRejected || status == StatusIncomplete
+}
+
+// checkTransition returns an error unless a transaction of the given type may
+// move from one status to another.
+func checkTransition(transactionType, from, to string) error {
+	if !isKnownStatus(transactionType, to) {
+		return utils.ErrInvalidStatus
+	}
+
+	for _, next := range allowedTransitions(transactionType, from) {
+		if next == to {
+			return nil
+		}
+	}
+
+	return &utils.TransitionError{TransactionType: transactionType, From: from, To: to}
+}
@@ -0,0 +1,69 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"gtihub.com/raditsoic/telkom-storage-ms/src/utils"
+)
+
+func TestCheckTransition(t *testing.T) {
+	tests := []struct {
+		name            string
+		transactionType string
+		from            string
+		to              string
+		want            error
+	}{
+		{"loan pending to approved", "loan", StatusPending, StatusApproved, nil},
+		{"loan pending to incomplete", "loan", StatusPending, StatusIncomplete, nil},
+		{"loan pending to rejected", "loan", StatusPending, StatusRejected, nil},
+		{"loan incomplete to approved", "loan", StatusIncomplete, StatusApproved, nil},
+		{"loan approved to completed", "loan", StatusApproved, StatusCompleted, nil},
+		{"loan completed to returned", "loan", StatusCompleted, StatusReturned, nil},
+		{"loan overdue to returned", "loan", StatusOverdue, StatusReturned, nil},
+		{"loan pending to completed skips approval", "loan", StatusPending, StatusCompleted, utils.ErrInvalidTransition},
+		{"loan incomplete to completed skips approval", "loan", StatusIncomplete, StatusCompleted, utils.ErrInvalidTransition},
+		{"loan rejected is final", "loan", StatusRejected, StatusApproved, utils.ErrInvalidTransition},
+		{"loan returned is final", "loan", StatusReturned, StatusCompleted, utils.ErrInvalidTransition},
+		{"loan cannot be moved to overdue by hand", "loan", StatusCompleted, StatusOverdue, utils.ErrInvalidTransition},
+		{"loan same status", "loan", StatusApproved, StatusApproved, utils.ErrInvalidTransition},
+		{"inquiry approved to completed", "inquiry", StatusApproved, StatusCompleted, nil},
+		{"inquiry cannot be returned", "inquiry", StatusCompleted, StatusReturned, utils.ErrInvalidStatus},
+		{"insert approved to completed", "insert", StatusApproved, StatusCompleted, nil},
+		{"insert completed is final", "insert", StatusCompleted, StatusRejected, utils.ErrInvalidTransition},
+		{"transfer pending to approved", "transfer", StatusPending, StatusApproved, nil},
+		{"transfer approved to incomplete", "transfer", StatusApproved, StatusIncomplete, nil},
+		{"transfer cannot be returned", "transfer", StatusCompleted, StatusReturned, utils.ErrInvalidStatus},
+		{"cancelled is not set through the table", "loan", StatusPending, StatusCancelled, utils.ErrInvalidStatus},
+		{"unknown status", "loan", StatusPending, "archived", utils.ErrInvalidStatus},
+		{"unknown transaction type", "purchase", StatusPending, StatusApproved, utils.ErrInvalidStatus},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkTransition(tt.transactionType, tt.from, tt.to)
+			if tt.want == nil {
+				if err != nil {
+					t.Fatalf("checkTransition(%q, %q, %q) = %v, want nil", tt.transactionType, tt.from, tt.to, err)
+				}
+				return
+			}
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("checkTransition(%q, %q, %q) = %v, want %v", tt.transactionType, tt.from, tt.to, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckTransitionReportsStatuses(t *testing.T) {
+	err := checkTransition("loan", StatusReturned, StatusCompleted)
+
+	var transitionErr *utils.TransitionError
+	if !errors.As(err, &transitionErr) {
+		t.Fatalf("checkTransition returned %v, want a *utils.TransitionError", err)
+	}
+	if transitionErr.TransactionType != "loan" || transitionErr.From != StatusReturned || transitionErr.To != StatusCompleted {
+		t.Fatalf("checkTransition reported %+v", transitionErr)
+	}
+}
@@ -0,0 +1,68 @@
+package service
+
+import (
+	"gtihub.com/raditsoic/telkom-storage-ms/src/database/repository"
+	"gtihub.com/raditsoic/telkom-storage-ms/src/model"
+	"gtihub.com/raditsoic/telkom-storage-ms/src/utils"
+)
+
+// Reasons recorded in the stock ledger
+const (
+	MovementOpeningBalance = "opening_balance"
+	MovementItemCreated    = "item_created"
+	MovementLoan           = "loan"
+	MovementLoanReturn     = "loan_return"
+	MovementInquiry        = "inquiry"
+	MovementInsertion      = "insertion"
+	MovementTransferOut    = "transfer_out"
+	MovementTransferIn     = "transfer_in"
+)
+
+// moveReservedStock applies the stock effect of moving a loan or inquiry from
+// one status to another. Approval reserves the requested quantity, completion
+// consumes the reservation and any other move away from approved releases it.
+// Consumed stock is recorded in the ledger under reason.
+func moveReservedStock(itemRepository *repository.ItemRepository, itemID uint, quantity int, from, to, reason string) error {
+	if from != StatusApproved && to != StatusApproved {
+		return nil
+	}
+
+	if _, err := itemRepository.GetItemByIDForUpdate(itemID); err != nil {
+		return utils.ErrItemNotFound
+	}
+
+	switch {
+	case to == StatusApproved:
+		return itemRepository.ReserveItemQuantity(itemID, quantity)
+	case to == StatusCompleted:
+		return itemRepository.ConsumeReservedItemQuantity(itemID, quantity, reason)
+	default:
+		return itemRepository.ReleaseItemQuantity(itemID, quantity)
+	}
+}
+
+// checkNotCounting refuses to move the stock of items kept in a storage that
+// is being counted, since committing the count would undo the movement.
+func checkNotCounting(opnameRepository *repository.StockOpnameRepository, itemIDs []uint) error {
+	counting, err := opnameRepository.IsCountingItems(itemIDs)
+	if err != nil {
+		return err
+	}
+	if counting {
+		return utils.ErrStockOpnameInProgress
+	}
+
+	return nil
+}
+
+// lineItemIDs returns the items of the lines in the given status.
+func lineItemIDs(lines []model.TransactionLine, status string) []uint {
+	itemIDs := make([]uint, 0, len(lines))
+	for _, line := range lines {
+		if line.Status == status {
+			itemIDs = append(itemIDs, line.ItemID)
+		}
+	}
+
+	return itemIDs
+}
@@ -0,0 +1,130 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+
+	"gtihub.com/raditsoic/telkom-storage-ms/src/model"
+	"gtihub.com/raditsoic/telkom-storage-ms/src/utils"
+)
+
+// newTrackingToken generates the token handed to a requester at creation,
+// along with the hash stored in its place.
+func newTrackingToken() (string, string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", fmt.Errorf("failed to generate tracking token: %w", err)
+	}
+
+	token := base64.RawURLEncoding.EncodeToString(buf)
+	return token, hashTrackingToken(token), nil
+}
+
+func hashTrackingToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// checkTrackingToken compares a presented token with the stored hash in
+// constant time. Transactions created before tracking existed have no hash
+// and cannot be tracked.
+func checkTrackingToken(hash, token string) error {
+	if hash == "" || subtle.ConstantTimeCompare([]byte(hash), []byte(hashTrackingToken(token))) != 1 {
+		return utils.ErrTransactionNotFound
+	}
+
+	return nil
+}
+
+// TrackTransaction shows a requester the progress of their own transaction.
+// A wrong token is reported the same way as an unknown transaction.
+func (s *TransactionService) TrackTransaction(uuidStr, token string) (*model.TrackTransactionResponse, error) {
+	if token == "" {
+		return nil, utils.ErrTrackingTokenRequired
+	}
+
+	transactionType, uuid, err := parseTransactionID(uuidStr)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &model.TrackTransactionResponse{
+		ID:              uuidStr,
+		TransactionType: transactionType,
+		Timeline:        []model.TrackingEvent{},
+	}
+
+	switch transactionType {
+	case "loan":
+		loan, err := s.logRepository.GetLoanTransactionByUUID(uuid)
+		if err != nil {
+			return nil, utils.ErrTransactionNotFound
+		}
+		if err := checkTrackingToken(loan.TrackingTokenHash, token); err != nil {
+			return nil, err
+		}
+
+		returnTime := loan.ReturnTime
+		response.Status = loan.Status
+		response.StatusReason = loan.StatusReason
+		response.Time = loan.Time
+		response.ReturnTime = &returnTime
+		response.CompletedTime = loan.CompletedTime
+		response.Lines = loan.Lines
+	case "inquiry":
+		inquiry, err := s.logRepository.GetInquiryTransactionByUUID(uuid)
+		if err != nil {
+			return nil, utils.ErrTransactionNotFound
+		}
+		if err := checkTrackingToken(inquiry.TrackingTokenHash, token); err != nil {
+			return nil, err
+		}
+
+		response.Status = inquiry.Status
+		response.StatusReason = inquiry.StatusReason
+		response.Time = inquiry.Time
+		response.CompletedTime = inquiry.CompletedTime
+		response.Lines = inquiry.Lines
+	case "insert":
+		insertion, err := s.logRepository.GetInsertionTransactionByUUID(uuid)
+		if err != nil {
+			return nil, utils.ErrTransactionNotFound
+		}
+		if err := checkTrackingToken(insertion.TrackingTokenHash, token); err != nil {
+			return nil, err
+		}
+
+		response.Status = insertion.Status
+		response.StatusReason = insertion.StatusReason
+		response.Time = insertion.Time
+		response.CompletedTime = insertion.CompletedTime
+	default:
+		return nil, utils.ErrTransactionType
+	}
+
+	response.Cancellable = isCancellable(response.Status)
+
+	events, err := s.logRepository.GetTransactionEvents(transactionType, uuid)
+	if err != nil {
+		return nil, err
+	}
+	for _, event := range events {
+		// Trash operations are admin bookkeeping, not progress of the request.
+		if event.Action != "" {
+			continue
+		}
+		response.Timeline = append(response.Timeline, model.TrackingEvent{
+			LineID:     event.LineID,
+			FromStatus: event.FromStatus,
+			ToStatus:   event.ToStatus,
+			Reason:     event.Reason,
+			Time:       event.Time,
+		})
+	}
+
+	return response, nil
+}
@@ -0,0 +1,261 @@
+package service
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gtihub.com/raditsoic/telkom-storage-ms/src/database/repository"
+	"gtihub.com/raditsoic/telkom-storage-ms/src/model"
+	"gtihub.com/raditsoic/telkom-storage-ms/src/utils"
+)
+
+func transferTransactionResponse(transfer model.TransferTransaction) model.GetAllTransactionsResponse {
+	return model.GetAllTransactionsResponse{
+		UUID:            fmt.Sprintf("%s_%s", "transfer", transfer.UUID),
+		TransactionType: transfer.TransactionType,
+		Status:          transfer.Status,
+		Time:            transfer.Time,
+		Notes:           transfer.Notes,
+		Quantity:        transfer.Quantity,
+		CompletedTime:   transfer.CompletedTime,
+		StatusReason:    transfer.StatusReason,
+		AdminNote:       transfer.AdminNote,
+		DeletedTime:     deletedTime(transfer.DeletedAt),
+		DeletedBy:       transfer.DeletedBy,
+		DeleteReason:    transfer.DeleteReason,
+		Transfer: &model.TransferDetails{
+			SourceItemID:          transfer.SourceItemID,
+			SourceItem:            transfer.SourceItem,
+			DestinationStorageID:  transfer.DestinationStorageID,
+			DestinationCategoryID: transfer.DestinationCategoryID,
+			DestinationShelf:      transfer.DestinationShelf,
+			DestinationItemID:     transfer.DestinationItemID,
+			DestinationItem:       transfer.DestinationItem,
+		},
+	}
+}
+
+// CreateTransferTransaction records an admin's request to move stock of an
+// item to another storage, category or shelf. Nothing moves until the
+// transfer is approved and completed. The available quantity is only checked
+// here as a hint; approval reserves it and is where it is enforced.
+func (s *TransactionService) CreateTransferTransaction(req model.CreateTransferTransactionRequest, adminID *uint) (*model.CreateTransferTransactionResponse, error) {
+	if req.Quantity <= 0 {
+		return nil, utils.ErrInvalidQuantity
+	}
+
+	item, err := s.itemRepository.GetItemByID(strconv.FormatUint(uint64(req.SourceItemID), 10))
+	if err != nil {
+		return nil, fmt.Errorf("item with ID %d not found: %w", req.SourceItemID, utils.ErrItemNotFound)
+	}
+	if item.Available < req.Quantity {
+		return nil, utils.ErrInsufficientQuantity
+	}
+
+	category, err := s.categoryRepository.GetCategoryByID(strconv.FormatUint(uint64(req.DestinationCategoryID), 10))
+	if err != nil {
+		return nil, utils.ErrCategoryNotFound
+	}
+	if category.StorageID != req.DestinationStorageID {
+		return nil, fmt.Errorf("%w: category %d is not in storage %d", utils.ErrInvalidTransfer, category.ID, req.DestinationStorageID)
+	}
+
+	// Without a shelf the stock keeps the one it is on.
+	shelf := strings.TrimSpace(req.DestinationShelf)
+	if shelf == "" {
+		shelf = strings.TrimSpace(item.Shelf)
+	}
+	if category.ID == item.CategoryID && strings.EqualFold(shelf, strings.TrimSpace(item.Shelf)) {
+		return nil, fmt.Errorf("%w: destination is where the item already is", utils.ErrInvalidTransfer)
+	}
+
+	if err := s.checkTransferCounting(item.ID, category.ID); err != nil {
+		return nil, err
+	}
+
+	transfer := &model.TransferTransaction{
+		UUID:                  uuid.New(),
+		TransactionType:       "transfer",
+		SourceItemID:          item.ID,
+		DestinationStorageID:  category.StorageID,
+		DestinationCategoryID: category.ID,
+		DestinationShelf:      shelf,
+		Quantity:              req.Quantity,
+		Status:                StatusPending,
+		Notes:                 req.Notes,
+		Time:                  time.Now(),
+		CreatedBy:             adminID,
+	}
+
+	err = s.logRepository.Transaction(func(tx *gorm.DB) error {
+		logRepository := s.logRepository.WithTx(tx)
+
+		if _, err := logRepository.CreateTransferTransaction(transfer); err != nil {
+			return err
+		}
+
+		return recordStatusChange(logRepository, "transfer", transfer.UUID, nil, "", StatusPending, adminID, "", "")
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create transfer transaction: %w", err)
+	}
+
+	return &model.CreateTransferTransactionResponse{
+		Message:               "Transfer transaction created successfully",
+		ID:                    transfer.UUID.String(),
+		Status:                transfer.Status,
+		SourceItem:            item,
+		DestinationStorageID:  transfer.DestinationStorageID,
+		DestinationCategoryID: transfer.DestinationCategoryID,
+		DestinationShelf:      transfer.DestinationShelf,
+		Quantity:              transfer.Quantity,
+	}, nil
+}
+
+// checkTransferCounting refuses a transfer while the storage on either side
+// of it is being counted.
+func (s *TransactionService) checkTransferCounting(sourceItemID, destinationCategoryID uint) error {
+	counting, err := s.opnameRepository.IsCountingItems([]uint{sourceItemID})
+	if err != nil {
+		return err
+	}
+	if !counting {
+		counting, err = s.opnameRepository.IsCountingCategory(destinationCategoryID)
+		if err != nil {
+			return err
+		}
+	}
+	if counting {
+		return utils.ErrStockOpnameInProgress
+	}
+
+	return nil
+}
+
+// matchTransferItem looks for the item a transfer adds to: one with the same
+// normalized name in the destination category and on the destination shelf.
+func matchTransferItem(itemRepository *repository.ItemRepository, name string, categoryID uint, shelf string) (*model.Item, error) {
+	items, err := itemRepository.GetItemsInStorageOfCategory(categoryID)
+	if err != nil {
+		return nil, err
+	}
+
+	name = normalizeItemName(name)
+	for i := range items {
+		if items[i].CategoryID == categoryID && normalizeItemName(items[i].Name) == name && strings.EqualFold(strings.TrimSpace(items[i].Shelf), shelf) {
+			return &items[i], nil
+		}
+	}
+
+	return nil, nil
+}
+
+// stockTransfer moves the quantity of a completed transfer out of the source
+// item's reservation and into the destination item, creating it when the
+// destination has none. It returns the destination item. A source item that
+// was moved to the destination since the transfer was created cannot be
+// transferred onto itself.
+func stockTransfer(itemRepository *repository.ItemRepository, transfer *model.TransferTransaction) (uint, error) {
+	source, err := itemRepository.GetItemByIDForUpdate(transfer.SourceItemID)
+	if err != nil {
+		return 0, utils.ErrItemNotFound
+	}
+
+	destination, err := matchTransferItem(itemRepository, source.Name, transfer.DestinationCategoryID, transfer.DestinationShelf)
+	if err != nil {
+		return 0, err
+	}
+	if destination != nil && destination.ID == source.ID {
+		return 0, fmt.Errorf("%w: item %d is already at the destination", utils.ErrInvalidTransfer, source.ID)
+	}
+
+	if err := itemRepository.ConsumeReservedItemQuantity(source.ID, transfer.Quantity, MovementTransferOut); err != nil {
+		return 0, err
+	}
+
+	if destination != nil {
+		if _, err := itemRepository.GetItemByIDForUpdate(destination.ID); err != nil {
+			return 0, utils.ErrItemNotFound
+		}
+		if err := itemRepository.AdjustItemQuantity(destination.ID, transfer.Quantity, MovementTransferIn); err != nil {
+			return 0, fmt.Errorf("failed to update destination item: %w", err)
+		}
+
+		return destination.ID, nil
+	}
+
+	createdItem, err := itemRepository.CreateItem(&model.Item{
+		Name:       source.Name,
+		Quantity:   transfer.Quantity,
+		Shelf:      transfer.DestinationShelf,
+		CategoryID: transfer.DestinationCategoryID,
+	}, MovementTransferIn)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create destination item: %w", err)
+	}
+
+	return createdItem.ID, nil
+}
+
+func (s *TransactionService) updateTransferTransaction(uuid uuid.UUID, status string, adminID *uint, req model.UpdateTransactionStatusRequest) (*model.UpdateTransactionResponse, error) {
+	err := s.logRepository.Transaction(func(tx *gorm.DB) error {
+		logRepository := s.logRepository.WithTx(tx)
+		itemRepository := s.itemRepository.WithTx(tx).WithSource(fmt.Sprintf("%s_%s", "transfer", uuid), adminID)
+
+		transfer, err := logRepository.GetTransferTransactionByUUIDForUpdate(uuid)
+		if err != nil {
+			return utils.ErrTransactionNotFound
+		}
+
+		if err := checkTransition("transfer", transfer.Status, status); err != nil {
+			return err
+		}
+
+		switch status {
+		case StatusApproved, StatusCompleted:
+			if err := s.withTx(tx).checkTransferCounting(transfer.SourceItemID, transfer.DestinationCategoryID); err != nil {
+				return err
+			}
+		}
+
+		if status == StatusCompleted {
+			itemID, err := stockTransfer(itemRepository, transfer)
+			if err != nil {
+				return err
+			}
+
+			transfer.DestinationItemID = &itemID
+			now := time.Now()
+			transfer.CompletedTime = &now
+		} else if err := moveReservedStock(itemRepository, transfer.SourceItemID, transfer.Quantity, transfer.Status, status, MovementTransferOut); err != nil {
+			return err
+		}
+
+		if err := recordStatusChange(logRepository, "transfer", transfer.UUID, nil, transfer.Status, status, adminID, req.Reason, req.Comment); err != nil {
+			return err
+		}
+
+		transfer.Status = status
+		transfer.StatusReason = req.Reason
+		if req.AdminNote != "" {
+			transfer.AdminNote = req.AdminNote
+		}
+		if err := logRepository.UpdateTransferTransaction(transfer); err != nil {
+			return fmt.Errorf("failed to update transfer transaction: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.UpdateTransactionResponse{
+		Message: fmt.Sprintf("Transfer transaction %s successfully", status),
+		ID:      uuid.String(),
+	}, nil
+}
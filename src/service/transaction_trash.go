@@ -0,0 +1,265 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gtihub.com/raditsoic/telkom-storage-ms/src/database/repository"
+	"gtihub.com/raditsoic/telkom-storage-ms/src/model"
+	"gtihub.com/raditsoic/telkom-storage-ms/src/utils"
+)
+
+// transactionTypeNames names each transaction type in response messages.
+var transactionTypeNames = map[string]string{
+	"loan":     "Loan",
+	"inquiry":  "Inquiry",
+	"insert":   "Insertion",
+	"transfer": "Transfer",
+}
+
+// Trash operations recorded on the timeline of a transaction.
+const (
+	TrashActionTrashed  = "trashed"
+	TrashActionRestored = "restored"
+	TrashActionPurged   = "purged"
+)
+
+// recordTrashEvent adds a trash operation to the timeline of a transaction.
+// The status does not change, so it is recorded on both sides.
+func recordTrashEvent(logRepository *repository.TransactionRepository, transactionType string, transactionUUID uuid.UUID, status, action string, adminID *uint, reason string) error {
+	return logRepository.CreateTransactionEvent(&model.TransactionEvent{
+		TransactionType: transactionType,
+		TransactionUUID: transactionUUID,
+		FromStatus:      status,
+		ToStatus:        status,
+		Action:          action,
+		AdminID:         adminID,
+		Reason:          reason,
+		Time:            time.Now(),
+	})
+}
+
+// DeleteTransaction moves a transaction to the trash on behalf of an admin.
+// Transactions that still hold stock have to be closed first, so that the
+// trash never hides a reservation or loaned unit. The status is left as it
+// is, so restoring brings the transaction back unchanged.
+func (s *TransactionService) DeleteTransaction(uuidStr string, adminID *uint, req model.DeleteTransactionRequest) (*model.DeleteTransactionResponse, error) {
+	transactionType, uuid, err := parseTransactionID(uuidStr)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := transactionTypeNames[transactionType]; !ok {
+		return nil, utils.ErrTransactionType
+	}
+
+	reason := strings.TrimSpace(req.Reason)
+	err = s.logRepository.Transaction(func(tx *gorm.DB) error {
+		logRepository := s.logRepository.WithTx(tx)
+
+		var id uint
+		var status string
+		switch transactionType {
+		case "loan":
+			loan, err := logRepository.GetLoanTransactionByUUIDForUpdate(uuid)
+			if err != nil {
+				return utils.ErrTransactionNotFound
+			}
+			id, status = loan.ID, loan.Status
+		case "inquiry":
+			inquiry, err := logRepository.GetInquiryTransactionByUUIDForUpdate(uuid)
+			if err != nil {
+				return utils.ErrTransactionNotFound
+			}
+			id, status = inquiry.ID, inquiry.Status
+		case "insert":
+			insertion, err := logRepository.GetInsertionTransactionByUUIDForUpdate(uuid)
+			if err != nil {
+				return utils.ErrTransactionNotFound
+			}
+			id, status = insertion.ID, insertion.Status
+		case "transfer":
+			transfer, err := logRepository.GetTransferTransactionByUUIDForUpdate(uuid)
+			if err != nil {
+				return utils.ErrTransactionNotFound
+			}
+			id, status = transfer.ID, transfer.Status
+		}
+
+		held, err := transactionHoldsStock(logRepository, transactionType, id, status)
+		if err != nil {
+			return err
+		}
+		if held {
+			return utils.ErrTransactionHoldsStock
+		}
+
+		if err := logRepository.SoftDeleteTransaction(transactionType, id, adminID, reason, time.Now()); err != nil {
+			return err
+		}
+
+		return recordTrashEvent(logRepository, transactionType, uuid, status, TrashActionTrashed, adminID, reason)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.DeleteTransactionResponse{
+		Message: fmt.Sprintf("%s transaction moved to trash successfully", transactionTypeNames[transactionType]),
+		ID:      uuid.String(),
+	}, nil
+}
+
+// getDeletedTransactionForUpdate locks a transaction in the trash and
+// returns its ID and status.
+func getDeletedTransactionForUpdate(logRepository *repository.TransactionRepository, transactionType string, transactionUUID uuid.UUID) (uint, string, error) {
+	switch transactionType {
+	case "loan":
+		loan, err := logRepository.GetDeletedLoanTransactionByUUIDForUpdate(transactionUUID)
+		if err != nil {
+			return 0, "", utils.ErrTransactionNotFound
+		}
+		return loan.ID, loan.Status, nil
+	case "inquiry":
+		inquiry, err := logRepository.GetDeletedInquiryTransactionByUUIDForUpdate(transactionUUID)
+		if err != nil {
+			return 0, "", utils.ErrTransactionNotFound
+		}
+		return inquiry.ID, inquiry.Status, nil
+	case "insert":
+		insertion, err := logRepository.GetDeletedInsertionTransactionByUUIDForUpdate(transactionUUID)
+		if err != nil {
+			return 0, "", utils.ErrTransactionNotFound
+		}
+		return insertion.ID, insertion.Status, nil
+	case "transfer":
+		transfer, err := logRepository.GetDeletedTransferTransactionByUUIDForUpdate(transactionUUID)
+		if err != nil {
+			return 0, "", utils.ErrTransactionNotFound
+		}
+		return transfer.ID, transfer.Status, nil
+	default:
+		return 0, "", utils.ErrTransactionType
+	}
+}
+
+// RestoreTransaction takes a transaction out of the trash on behalf of an
+// admin.
+func (s *TransactionService) RestoreTransaction(uuidStr string, adminID *uint) (*model.RestoreTransactionResponse, error) {
+	transactionType, uuid, err := parseTransactionID(uuidStr)
+	if err != nil {
+		return nil, err
+	}
+
+	var status string
+	err = s.logRepository.Transaction(func(tx *gorm.DB) error {
+		logRepository := s.logRepository.WithTx(tx)
+
+		var id uint
+		id, status, err = getDeletedTransactionForUpdate(logRepository, transactionType, uuid)
+		if err != nil {
+			return err
+		}
+
+		if err := logRepository.RestoreTransaction(transactionType, id); err != nil {
+			return err
+		}
+
+		return recordTrashEvent(logRepository, transactionType, uuid, status, TrashActionRestored, adminID, "")
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.RestoreTransactionResponse{
+		Message: fmt.Sprintf("%s transaction restored successfully", transactionTypeNames[transactionType]),
+		ID:      uuid.String(),
+		Status:  status,
+	}, nil
+}
+
+// holdsStock reports whether any line still has stock reserved for it or,
+// for loans, units that have not been brought back.
+func holdsStock(transactionType string, lines []model.TransactionLine) bool {
+	for _, line := range lines {
+		if line.Status == StatusApproved {
+			return true
+		}
+		if transactionType == "loan" && line.Status == StatusCompleted && line.Quantity > line.ReturnedQuantity {
+			return true
+		}
+	}
+
+	return false
+}
+
+// transactionHoldsStock reports whether a locked transaction still has stock
+// reserved for it or loaned out.
+func transactionHoldsStock(logRepository *repository.TransactionRepository, transactionType string, id uint, status string) (bool, error) {
+	switch transactionType {
+	case "transfer":
+		return status == StatusApproved, nil
+	case "loan", "inquiry":
+		lines, err := logRepository.GetTransactionLinesForUpdate(transactionType, id)
+		if err != nil {
+			return false, err
+		}
+		return holdsStock(transactionType, lines), nil
+	default:
+		return false, nil
+	}
+}
+
+// PurgeTransaction permanently removes a transaction from the trash on behalf
+// of an admin, along with its lines and approvals. Transactions trashed while
+// they still held stock have to be closed first. The timeline, write-offs and
+// quota violations are kept as the audit trail of the transaction.
+func (s *TransactionService) PurgeTransaction(uuidStr string, adminID *uint) (*model.DeleteTransactionResponse, error) {
+	transactionType, uuid, err := parseTransactionID(uuidStr)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.logRepository.Transaction(func(tx *gorm.DB) error {
+		logRepository := s.logRepository.WithTx(tx)
+
+		id, status, err := getDeletedTransactionForUpdate(logRepository, transactionType, uuid)
+		if err != nil {
+			return err
+		}
+
+		held, err := transactionHoldsStock(logRepository, transactionType, id, status)
+		if err != nil {
+			return err
+		}
+		if held {
+			return utils.ErrTransactionHoldsStock
+		}
+
+		switch transactionType {
+		case "loan":
+			err = logRepository.DeleteLoanTransactionByUUID(uuid)
+		case "inquiry":
+			err = logRepository.DeleteInquiryTransactionByUUID(uuid)
+		case "insert":
+			err = logRepository.DeleteInsertionTransactionByUUID(uuid)
+		case "transfer":
+			err = logRepository.DeleteTransferTransactionByUUID(uuid)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to purge %s transaction: %w", transactionType, err)
+		}
+
+		return recordTrashEvent(logRepository, transactionType, uuid, status, TrashActionPurged, adminID, "")
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.DeleteTransactionResponse{
+		Message: fmt.Sprintf("%s transaction purged successfully", transactionTypeNames[transactionType]),
+		ID:      uuid.String(),
+	}, nil
+}
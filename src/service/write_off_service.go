@@ -0,0 +1,75 @@
+package service
+
+import (
+	"strings"
+
+	"gtihub.com/raditsoic/telkom-storage-ms/src/database/repository"
+	"gtihub.com/raditsoic/telkom-storage-ms/src/model"
+	"gtihub.com/raditsoic/telkom-storage-ms/src/utils"
+)
+
+type WriteOffService struct {
+	writeOffRepository repository.WriteOffRepository
+}
+
+func NewWriteOffService(writeOff repository.WriteOffRepository) *WriteOffService {
+	return &WriteOffService{writeOffRepository: writeOff}
+}
+
+func checkWriteOffFilter(filter model.WriteOffFilter) error {
+	if filter.Condition != "" && filter.Condition != ReturnConditionDamaged && filter.Condition != ReturnConditionLost {
+		return utils.ErrInvalidReturnCondition
+	}
+
+	return nil
+}
+
+func (service *WriteOffService) GetWriteOffs(filter model.WriteOffFilter, page, limit int) (*model.GetWriteOffsResponse, error) {
+	if err := checkWriteOffFilter(filter); err != nil {
+		return nil, err
+	}
+
+	writeOffs, total, err := service.writeOffRepository.GetWriteOffs(filter, limit, (page-1)*limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.GetWriteOffsResponse{
+		WriteOffs: writeOffs,
+		Total:     total,
+		Page:      page,
+		Limit:     limit,
+	}, nil
+}
+
+// GetLiabilityReport sums the damaged and lost units charged to each
+// employee, or to each department when groupBy is department.
+func (service *WriteOffService) GetLiabilityReport(groupBy string, filter model.WriteOffFilter) (*model.LiabilityReportResponse, error) {
+	if err := checkWriteOffFilter(filter); err != nil {
+		return nil, err
+	}
+
+	groupBy = strings.ToLower(groupBy)
+	var entries []model.LiabilityEntry
+	var err error
+	switch groupBy {
+	case "", "employee":
+		groupBy = "employee"
+		entries, err = service.writeOffRepository.GetLiabilityByEmployee(filter)
+	case "department":
+		entries, err = service.writeOffRepository.GetLiabilityByDepartment(filter)
+	default:
+		return nil, utils.ErrInvalidLiabilityGroup
+	}
+	if err != nil {
+		return nil, err
+	}
+	if entries == nil {
+		entries = []model.LiabilityEntry{}
+	}
+
+	return &model.LiabilityReportResponse{
+		GroupBy: groupBy,
+		Entries: entries,
+	}, nil
+}
@@ -1,6 +1,9 @@
 package utils
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 var ErrUsernameExists = errors.New("username already exists")
 
@@ -14,4 +17,116 @@ var ErrTransactionNotFound = errors.New("transaction not found")
 
 var ErrItemNotFound = errors.New("item not found")
 
-var ErrStorageNotFound = errors.New("storage not found")
\ No newline at end of file
+var ErrItemInUse = errors.New("item is referenced by transactions")
+
+var ErrItemBalanceNotFound = errors.New("no stock is recorded for the item at that time")
+
+var ErrStorageNotFound = errors.New("storage not found")
+
+var ErrInsufficientQuantity = errors.New("insufficient quantity")
+
+var ErrInvalidStatus = errors.New("invalid status")
+
+var ErrInvalidReturnQuantity = errors.New("invalid return quantity")
+
+var ErrReasonRequired = errors.New("reason is required")
+
+var ErrInvalidReturnCondition = errors.New("damaged and lost units must not be negative or exceed the returned quantity")
+
+var ErrInvalidLiabilityGroup = errors.New("invalid liability report grouping")
+
+var ErrInvalidReturnTime = errors.New("invalid return time")
+
+var ErrExtensionNotFound = errors.New("loan extension not found")
+
+var ErrExtensionPending = errors.New("loan already has a pending extension")
+
+var ErrInvalidQuantity = errors.New("quantity must be greater than 0")
+
+var ErrLineNotFound = errors.New("transaction line not found")
+
+var ErrInvalidTransition = errors.New("invalid status transition")
+
+var ErrInvalidSort = errors.New("invalid sort field")
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+var ErrCursorOrderMismatch = errors.New("cursor belongs to a listing in another order")
+
+var ErrStockOpnameNotFound = errors.New("stock opname not found")
+
+var ErrStockOpnameInProgress = errors.New("storage is being counted")
+
+var ErrStockOpnameClosed = errors.New("stock opname is no longer open")
+
+var ErrCounterRequired = errors.New("counter is required")
+
+var ErrInvalidCount = errors.New("counted quantity cannot be negative")
+
+var ErrCountBelowReserved = errors.New("counted quantity is below the reserved quantity")
+
+var ErrItemChoiceConflict = errors.New("choose either an existing item or a new item, not both")
+
+var ErrTrackingTokenRequired = errors.New("tracking token is required")
+
+var ErrSimilarItemExists = errors.New("similar items exist, choose an existing item or create a new one")
+
+var ErrEmployeeNotFound = errors.New("employee not found")
+
+var ErrEmployeeNameRequired = errors.New("employee name is required")
+
+var ErrEmployeeIDNumberExists = errors.New("employee ID number already exists")
+
+var ErrQuotaNotFound = errors.New("quota not found")
+
+var ErrInvalidQuota = errors.New("invalid quota")
+
+var ErrQuotaTargetNotFound = errors.New("employee, item or category of the quota not found")
+
+var ErrQuotaExceeded = errors.New("request exceeds a consumption quota")
+
+var ErrApprovalRuleNotFound = errors.New("approval rule not found")
+
+var ErrInvalidApprovalRule = errors.New("invalid approval rule")
+
+var ErrApprovalChainNotFound = errors.New("approval chain not found")
+
+var ErrInvalidApprovalChain = errors.New("invalid approval chain")
+
+var ErrApprovalStepNotFound = errors.New("no approval step is waiting for a decision")
+
+var ErrApprovalStepsPending = errors.New("approval chain has steps waiting for a decision")
+
+var ErrApproverRoleRequired = errors.New("admin does not hold the role of this approval step")
+
+var ErrApproverAlreadyDecided = errors.New("admin already decided an earlier step of this approval chain")
+
+var ErrAdminNotFound = errors.New("admin not found")
+
+var ErrTransactionHoldsStock = errors.New("transaction still holds reserved or loaned stock")
+
+var ErrInvalidTransfer = errors.New("invalid transfer")
+
+var ErrCategoryNotFound = errors.New("category not found")
+
+var ErrInvalidBulkRequest = errors.New("invalid bulk request")
+
+var ErrIdempotencyKeyReused = errors.New("idempotency key was already used with a different request")
+
+var ErrIdempotencyKeyInProgress = errors.New("a request with this idempotency key is still being processed")
+
+// TransitionError reports a status change that the transition table of a
+// transaction type does not allow. It matches ErrInvalidTransition.
+type TransitionError struct {
+	TransactionType string
+	From            string
+	To              string
+}
+
+func (e *TransitionError) Error() string {
+	return fmt.Sprintf("cannot change %s transaction from %s to %s", e.TransactionType, e.From, e.To)
+}
+
+func (e *TransitionError) Unwrap() error {
+	return ErrInvalidTransition
+}